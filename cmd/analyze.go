@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/spf13/cobra"
+)
+
+var (
+
+	// Used for flags.
+	analyzeMapFileName    string
+	analyzeMapFormat      string
+	analyzeReportFormat   string
+	analyzeOutputFileName string
+	analyzeTop            int
+	analyzeSamples        int
+	analyzeSeed           int64
+
+	analyzeCmd = &cobra.Command{
+		Use:   "analyze",
+		Short: "Report the structure of a map.",
+		Long:  `Report the number of cities and roads of a map, the number of roads of its cities, the groups of connected cities, the cities and roads whose loss splits the world, the diameter of the world and the cities on the most shortest paths between other cities.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Exit(analyze())
+		},
+	}
+)
+
+func init() {
+	analyzeCmd.Flags().StringVarP(&analyzeMapFileName, "mapFileName", "m", "map.txt", "Specify map file name.")
+	analyzeCmd.Flags().StringVar(&analyzeMapFormat, "format", "", "Specify map file format (text, json or yaml, defaults to the format matching the file extension).")
+	analyzeCmd.Flags().StringVarP(&analyzeReportFormat, "report", "r", "text", "Specify report format (text or json).")
+	analyzeCmd.Flags().StringVarP(&analyzeOutputFileName, "output", "o", "", "Specify file to write the report to (defaults to stdout).")
+	analyzeCmd.Flags().IntVar(&analyzeTop, "top", 10, "Specify number of most central cities to report (all of them if 0).")
+	analyzeCmd.Flags().IntVar(&analyzeSamples, "samples", 500, "Specify number of cities the shortest paths are measured from, estimating the diameter and the centrality on larger maps (every city if 0).")
+	analyzeCmd.Flags().Int64VarP(&analyzeSeed, "seed", "s", 0, "Specify seed for the choice of the sampled cities.")
+	rootCmd.AddCommand(analyzeCmd)
+}
+
+// analyze analyzes the map and writes the report, returning the exit code of the program
+func analyze() int {
+	if analyzeReportFormat != "text" && analyzeReportFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown report format %q.\n", analyzeReportFormat)
+		return 1
+	}
+
+	mapInfo, err := mapfile.LoadMapInfo(analyzeMapFileName, analyzeMapFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading the map: %v", err)
+		return 1
+	}
+	world := structs.CreateWorld()
+	world.InitializeWorld(mapInfo)
+	analysis := world.Analyze(structs.AnalysisOptions{Top: analyzeTop, Samples: analyzeSamples, Seed: analyzeSeed})
+
+	err = writeReport(analyzeOutputFileName, func(out io.Writer) error {
+		if analyzeReportFormat == "json" {
+			return writeJSON(out, analysis)
+		}
+		return writeAnalysisText(out, analysis)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing the report: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// writeAnalysisText writes the map analysis as human-readable tables
+func writeAnalysisText(out io.Writer, analysis *structs.MapAnalysis) error {
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(table, "Cities\t%d\n", analysis.Cities)
+	fmt.Fprintf(table, "Roads\t%d\n", analysis.Roads)
+	largest := 0
+	if len(analysis.Components) > 0 {
+		largest = analysis.Components[0]
+	}
+	fmt.Fprintf(table, "Components\t%d (largest with %d cities)\n", len(analysis.Components), largest)
+	fmt.Fprintf(table, "Articulation points\t%d\n", len(analysis.ArticulationPoints))
+	fmt.Fprintf(table, "Bridges\t%d\n", len(analysis.Bridges))
+	if analysis.Exact {
+		fmt.Fprintf(table, "Diameter\t%d\n", analysis.Diameter)
+	} else {
+		fmt.Fprintf(table, "Diameter\tat least %d (measured from %d cities)\n", analysis.Diameter, analysis.Sources)
+	}
+	fmt.Fprintf(table, "\n")
+
+	fmt.Fprintf(table, "Roads\tCities\tShare\n")
+	for _, degree := range analysis.Degrees {
+		fmt.Fprintf(table, "%d\t%d\t%.1f%%\n", degree.Degree, degree.Cities, 100*float64(degree.Cities)/float64(analysis.Cities))
+	}
+	fmt.Fprintf(table, "\n")
+
+	centrality := "Betweenness"
+	if !analysis.Exact {
+		centrality = "Estimated betweenness"
+	}
+	fmt.Fprintf(table, "City\t%s\tShare\n", centrality)
+	for _, city := range analysis.Centrality {
+		fmt.Fprintf(table, "%s\t%.1f\t%.2f%%\n", city.City, city.Betweenness, 100*city.Normalized)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if len(analysis.ArticulationPoints) > 0 {
+		fmt.Fprintf(out, "\nArticulation points:\n")
+		for _, city := range analysis.ArticulationPoints {
+			fmt.Fprintf(out, "  %s\n", city)
+		}
+	}
+	if len(analysis.Bridges) > 0 {
+		fmt.Fprintf(out, "\nBridges:\n")
+		for _, bridge := range analysis.Bridges {
+			fmt.Fprintf(out, "  %s - %s\n", bridge[0], bridge[1])
+		}
+	}
+	return nil
+}
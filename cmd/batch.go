@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AleksandarHr/AlienInvasion/simulation"
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/spf13/cobra"
+)
+
+var (
+
+	// Used for flags.
+	batchAliensCount    int
+	batchMapFileName    string
+	batchMapFormat      string
+	batchMaxIterations  int
+	batchRuns           int
+	batchWorkers        int
+	batchSeed           int64
+	batchReportFormat   string
+	batchOutputFileName string
+	batchStrategies     []string
+	batchFightResolver  string
+	batchMovementMode   string
+	batchSpawnStrategy  string
+	batchPlacement      string
+	batchWaves          string
+	batchMaxAliens      int
+
+	batchCmd = &cobra.Command{
+		Use:   "batch",
+		Short: "Run many independent simulations and aggregate their outcomes.",
+		Long:  `Run many independent simulations of the same map across a pool of workers, each with its own seed derived from the base seed, and report the distribution of their outcomes.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !cmd.Flags().Changed("seed") {
+				batchSeed = time.Now().UnixNano()
+			}
+			os.Exit(batch())
+		},
+	}
+)
+
+func init() {
+	batchCmd.Flags().IntVarP(&batchAliensCount, "alienCount", "N", 5, "Specify number of aliens.")
+	batchCmd.Flags().StringVarP(&batchMapFileName, "mapFileName", "m", "map.txt", "Specify map file name.")
+	batchCmd.Flags().StringVar(&batchMapFormat, "format", "", "Specify map file format (text, json or yaml, defaults to the format matching the file extension).")
+	batchCmd.Flags().IntVarP(&batchMaxIterations, "iterations", "i", 10000, "Specify number of maximum iterations.")
+	batchCmd.Flags().IntVarP(&batchRuns, "runs", "k", 1000, "Specify number of simulations to run.")
+	batchCmd.Flags().IntVarP(&batchWorkers, "workers", "w", 0, "Specify number of simulations to run in parallel (defaults to the number of CPUs).")
+	batchCmd.Flags().Int64VarP(&batchSeed, "seed", "s", 0, "Specify base seed the seed of every simulation is derived from (defaults to a time-based seed).")
+	batchCmd.Flags().StringVarP(&batchReportFormat, "report", "r", "text", "Specify report format (text, json or csv).")
+	batchCmd.Flags().StringVarP(&batchOutputFileName, "output", "o", "", "Specify file to write the report to (defaults to stdout).")
+	batchCmd.Flags().StringVar(&batchSpawnStrategy, "spawn", "uniform", "Specify spawn strategy.")
+	batchCmd.Flags().StringVar(&batchPlacement, "placement", "", "Specify file placing every alien in a city (overrides spawn).")
+	batchCmd.Flags().StringVar(&batchWaves, "waves", "", "Specify waves of aliens spawned during the simulations (e.g. 5@100/100).")
+	batchCmd.Flags().IntVar(&batchMaxAliens, "maxAliens", 0, "Specify total number of aliens after which the waves stop.")
+	batchCmd.Flags().StringArrayVar(&batchStrategies, "strategy", nil, "Specify movement strategy, or ID=strategy for a single alien. Can be repeated.")
+	batchCmd.Flags().StringVar(&batchFightResolver, "fight", "mutual-destruction", "Specify fight resolver.")
+	batchCmd.Flags().StringVar(&batchMovementMode, "movement", "sequential", "Specify movement mode (sequential or simultaneous).")
+	rootCmd.AddCommand(batchCmd)
+}
+
+// batch runs the simulations and writes the report, returning the exit code of the program
+func batch() int {
+	if batchReportFormat != "text" && batchReportFormat != "json" && batchReportFormat != "csv" {
+		fmt.Fprintf(os.Stderr, "Unknown report format %q.\n", batchReportFormat)
+		return 1
+	}
+	if batchRuns < 1 {
+		fmt.Fprintf(os.Stderr, "Invalid number of runs %d, expected at least 1.\n", batchRuns)
+		return 1
+	}
+
+	spawnStrategy, err := parseSpawnStrategy(batchSpawnStrategy, batchPlacement)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the spawn strategy: %v", err)
+		return 1
+	}
+	waves, err := parseWaves(batchWaves, batchMaxAliens)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the waves: %v", err)
+		return 1
+	}
+	movementStrategy, alienStrategies, err := parseStrategies(batchStrategies)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the movement strategies: %v", err)
+		return 1
+	}
+	fightResolver, err := structs.ParseFightResolver(batchFightResolver)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the fight resolver: %v", err)
+		return 1
+	}
+	movementMode, err := simulation.ParseMovementMode(batchMovementMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the movement mode: %v", err)
+		return 1
+	}
+
+	report, err := simulation.RunBatch(simulation.BatchConfig{
+		Config: simulation.Config{
+			AliensCount:      batchAliensCount,
+			SpawnStrategy:    spawnStrategy,
+			Waves:            waves,
+			MapFileName:      batchMapFileName,
+			MapFormat:        batchMapFormat,
+			MaxIterations:    batchMaxIterations,
+			Seed:             batchSeed,
+			MovementStrategy: movementStrategy,
+			AlienStrategies:  alienStrategies,
+			FightResolver:    fightResolver,
+			Movement:         movementMode,
+		},
+		Runs:    batchRuns,
+		Workers: batchWorkers,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running the batch: %v", err)
+		return 1
+	}
+
+	err = writeReport(batchOutputFileName, func(out io.Writer) error {
+		switch batchReportFormat {
+		case "json":
+			return writeJSON(out, report)
+		case "csv":
+			return writeBatchCSV(out, report)
+		}
+		return writeBatchText(out, report)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing the report: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// writeReport writes a report to the given file, or to stdout if no file was specified
+func writeReport(fileName string, write func(out io.Writer) error) error {
+	if fileName == "" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return write(file)
+}
+
+// writeJSON writes the value as indented JSON
+func writeJSON(out io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// sortedStopReasons returns the stop reasons of a batch from the most to the least common
+func sortedStopReasons(report *simulation.BatchReport) []string {
+	reasons := []string{}
+	for reason := range report.StopReasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if report.StopReasons[reasons[i]] != report.StopReasons[reasons[j]] {
+			return report.StopReasons[reasons[i]] > report.StopReasons[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	return reasons
+}
+
+// writeBatchText writes the batch report as human-readable tables
+func writeBatchText(out io.Writer, report *simulation.BatchReport) error {
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(table, "%d runs with base seed %d on %d workers\n\n", report.Runs, report.Seed, report.Workers)
+
+	fmt.Fprintf(table, "Stop reason\tRuns\tShare\n")
+	for _, reason := range sortedStopReasons(report) {
+		count := report.StopReasons[reason]
+		fmt.Fprintf(table, "%s\t%d\t%.1f%%\n", reason, count, 100*float64(count)/float64(report.Runs))
+	}
+	fmt.Fprintf(table, "\n")
+
+	fmt.Fprintf(table, "Metric\tMin\tMean\tStdDev\tP50\tP90\tP99\tMax\n")
+	for _, metric := range []struct {
+		name         string
+		distribution simulation.Distribution
+	}{
+		{"Iterations", report.Iterations},
+		{"Surviving aliens", report.SurvivingAliens},
+		{"Destroyed cities", report.DestroyedCities},
+	} {
+		d := metric.distribution
+		fmt.Fprintf(table, "%s\t%g\t%.2f\t%.2f\t%g\t%g\t%g\t%g\n", metric.name, d.Min, d.Mean, d.StdDev, d.P50, d.P90, d.P99, d.Max)
+	}
+	fmt.Fprintf(table, "\n")
+
+	fmt.Fprintf(table, "City\tDestroyed\tProbability\n")
+	for _, city := range report.CityDestruction {
+		fmt.Fprintf(table, "%s\t%d\t%.1f%%\n", city.City, city.Destroyed, 100*city.Probability)
+	}
+	return table.Flush()
+}
+
+// writeBatchCSV writes the aggregated batch report as metric,key,value rows
+func writeBatchCSV(out io.Writer, report *simulation.BatchReport) error {
+	writer := csv.NewWriter(out)
+	formatFloat := func(value float64) string { return strconv.FormatFloat(value, 'g', -1, 64) }
+
+	writer.Write([]string{"metric", "key", "value"})
+	writer.Write([]string{"runs", "", strconv.Itoa(report.Runs)})
+	writer.Write([]string{"seed", "", strconv.FormatInt(report.Seed, 10)})
+	for _, reason := range sortedStopReasons(report) {
+		writer.Write([]string{"stopReason", reason, strconv.Itoa(report.StopReasons[reason])})
+	}
+	for _, metric := range []struct {
+		name         string
+		distribution simulation.Distribution
+	}{
+		{"iterations", report.Iterations},
+		{"survivingAliens", report.SurvivingAliens},
+		{"destroyedCities", report.DestroyedCities},
+	} {
+		d := metric.distribution
+		for _, stat := range []struct {
+			key   string
+			value float64
+		}{{"min", d.Min}, {"mean", d.Mean}, {"stdDev", d.StdDev}, {"p50", d.P50}, {"p90", d.P90}, {"p99", d.P99}, {"max", d.Max}} {
+			writer.Write([]string{metric.name, stat.key, formatFloat(stat.value)})
+		}
+	}
+	for _, city := range report.CityDestruction {
+		writer.Write([]string{"cityDestructionProbability", city.City, formatFloat(city.Probability)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
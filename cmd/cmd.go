@@ -1,11 +1,20 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/AleksandarHr/AlienInvasion/simulation"
+	"github.com/AleksandarHr/AlienInvasion/structs"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
@@ -13,14 +22,37 @@ var (
 	// Used for flags.
 	initialAliensCount int
 	mapFileName        string
+	mapFormat          string
 	maxIterations      int
+	outputFileName     string
+	seed               int64
+	repairMap          bool
+	repairedFileName   string
+	eventsFileName     string
+	checkpointEvery    int
+	checkpointFileName string
+	strategies         []string
+	fightResolverName  string
+	movementModeName   string
+	spawnStrategyName  string
+	placementFileName  string
+	wavesSpec          string
+	maxAliens          int
+	dotFileName        string
+	dotIterations      []int
+	dotOmitDestroyed   bool
+	timeout            time.Duration
 
 	rootCmd = &cobra.Command{
 		Use:   "AlienInvasion",
 		Short: "Simulate an alien invasion of a made-up world.",
 		Long:  `Create a made-up world and spawn aliens around it. Simulate alien movement around the world.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			run()
+			// without an explicit seed every run is different
+			if !cmd.Flags().Changed("seed") {
+				seed = time.Now().UnixNano()
+			}
+			os.Exit(run())
 		},
 	}
 )
@@ -30,17 +62,164 @@ func Execute() error {
 }
 
 func init() {
+	rootCmd.SetGlobalNormalizationFunc(kebabToCamelCase)
 	rootCmd.Flags().IntVarP(&initialAliensCount, "alienCount", "N", 5, "Specify number of aliens.")
 	rootCmd.Flags().StringVarP(&mapFileName, "mapFileName", "m", "map.txt", "Specify map file name.")
+	rootCmd.Flags().StringVar(&mapFormat, "format", "", "Specify map file format (text, json or yaml, defaults to the format matching the file extension).")
 	rootCmd.Flags().IntVarP(&maxIterations, "iterations", "i", 10000, "Specify number of maximum iterations.")
+	rootCmd.Flags().StringVarP(&outputFileName, "output", "o", "", "Specify file to write the remaining world to (defaults to stdout).")
+	rootCmd.Flags().BoolVar(&repairMap, "repair", false, "Infer missing reverse roads and cities of the map before simulating.")
+	rootCmd.Flags().StringVar(&repairedFileName, "repairOutput", "", "Specify file to write the repaired map to (defaults to the map file name with a .repaired suffix).")
+	rootCmd.Flags().StringVar(&dotFileName, "dotOut", "", "Specify file to render the remaining world to as a Graphviz DOT graph.")
+	rootCmd.Flags().IntSliceVar(&dotIterations, "dotAt", nil, "Specify iterations after which to also render the world, each to the DOT file name suffixed with the iteration (-1 for right after spawning).")
+	rootCmd.Flags().BoolVar(&dotOmitDestroyed, "dotOmitDestroyed", false, "Leave destroyed cities out of the DOT graph instead of greying them out.")
+	rootCmd.Flags().StringVar(&eventsFileName, "events", "", "Specify file to write the simulation events to as newline-delimited JSON.")
+	rootCmd.Flags().IntVar(&checkpointEvery, "checkpointEvery", 0, "Specify number of iterations between checkpoints (no periodic checkpoints if 0).")
+	rootCmd.Flags().StringVar(&checkpointFileName, "checkpoint", "", "Specify file to save checkpoints to, periodically and on interrupt.")
+	rootCmd.Flags().DurationVar(&timeout, "timeout", 0, "Specify wall-clock time after which the simulation is cancelled (e.g. 90s or 10m, no limit if 0).")
+	rootCmd.Flags().StringVar(&spawnStrategyName, "spawn", "uniform", "Specify spawn strategy (uniform, no-collision, degree-weighted or clustered:City[:radius]).")
+	rootCmd.Flags().StringVar(&placementFileName, "placement", "", "Specify file placing every alien in a city, one \"ID City\" pair per line (overrides spawn).")
+	rootCmd.Flags().StringVar(&wavesSpec, "waves", "", "Specify waves of aliens spawned during the simulation as COUNT@ITERATION[/EVERY] entries separated by commas (e.g. 5@100/100).")
+	rootCmd.Flags().IntVar(&maxAliens, "maxAliens", 0, "Specify total number of aliens after which the waves stop (required by repeated waves).")
+	rootCmd.Flags().StringArrayVar(&strategies, "strategy", nil, "Specify movement strategy (uniform, lazy[:p], degree-weighted, avoid-occupied, seek-nearest-alien or non-backtracking), or ID=strategy for a single alien. Can be repeated.")
+	rootCmd.Flags().StringVar(&fightResolverName, "fight", "mutual-destruction", "Specify fight resolver (mutual-destruction, probabilistic[:p], city-survives[:p], threshold:K or winner-takes-city).")
+	rootCmd.Flags().StringVar(&movementModeName, "movement", "sequential", "Specify whether aliens move one at a time (sequential) or all at once (simultaneous).")
+	rootCmd.Flags().Int64VarP(&seed, "seed", "s", 0, "Specify seed for the random choices to reproduce a run (defaults to a time-based seed).")
 }
 
-func run() {
-	simulation, err := simulation.CreateSimulation(initialAliensCount, mapFileName, maxIterations)
+// run executes the simulation and returns the exit code of the program
+func run() int {
+	mapInfo, err := loadMapInfo(mapFileName, mapFormat, repairMap, repairedFileName)
+	if err != nil {
+		fmt.Printf("Error loading the map: %v", err)
+		return 1
+	}
+
+	spawnStrategy, err := parseSpawnStrategy(spawnStrategyName, placementFileName)
+	if err != nil {
+		fmt.Printf("Error parsing the spawn strategy: %v", err)
+		return 1
+	}
+	waves, err := parseWaves(wavesSpec, maxAliens)
+	if err != nil {
+		fmt.Printf("Error parsing the waves: %v", err)
+		return 1
+	}
+	movementStrategy, alienStrategies, err := parseStrategies(strategies)
+	if err != nil {
+		fmt.Printf("Error parsing the movement strategies: %v", err)
+		return 1
+	}
+	fightResolver, err := structs.ParseFightResolver(fightResolverName)
+	if err != nil {
+		fmt.Printf("Error parsing the fight resolver: %v", err)
+		return 1
+	}
+	movementMode, err := simulation.ParseMovementMode(movementModeName)
+	if err != nil {
+		fmt.Printf("Error parsing the movement mode: %v", err)
+		return 1
+	}
+
+	config := simulation.Config{
+		AliensCount:        initialAliensCount,
+		SpawnStrategy:      spawnStrategy,
+		Waves:              waves,
+		MapFileName:        mapFileName,
+		MapInfo:            mapInfo,
+		MaxIterations:      maxIterations,
+		Seed:               seed,
+		CheckpointEvery:    checkpointEvery,
+		CheckpointFileName: checkpointFileName,
+		MovementStrategy:   movementStrategy,
+		AlienStrategies:    alienStrategies,
+		FightResolver:      fightResolver,
+		Movement:           movementMode,
+		DOTFileName:        dotFileName,
+		DOTIterations:      dotIterations,
+		DOTOptions:         structs.DOTOptions{OmitDestroyed: dotOmitDestroyed},
+	}
+	if eventsFileName != "" {
+		eventsFile, err := os.Create(eventsFileName)
+		if err != nil {
+			fmt.Printf("Error creating the events file: %v", err)
+			return 1
+		}
+		defer eventsFile.Close()
+		eventsWriter := bufio.NewWriter(eventsFile)
+		defer eventsWriter.Flush()
+		config.EventWriter = eventsWriter
+	}
+
+	simulation, err := simulation.CreateSimulation(config)
 	if err != nil {
 		fmt.Printf("Error creating a simulation: %v", err)
-		os.Exit(1)
+		return 1
+	}
+	if err := simulation.InitializeSimulation(); err != nil {
+		fmt.Printf("Error initializing the simulation: %v\n", err)
+		return 1
+	}
+	return runUntilDone(simulation, outputFileName, timeout)
+}
+
+// runUntilDone runs the simulation, cancelling it on SIGINT or SIGTERM or once the timeout (if
+// positive) expires, writes the remaining world and returns the exit code of the program
+func runUntilDone(sim *simulation.Simulation, outputFileName string, timeout time.Duration) int {
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result := sim.Run(ctx)
+	// a second signal kills the program while the world is written
+	stopSignals()
+
+	if err := writeMapTo(result.World, outputFileName); err != nil {
+		fmt.Printf("Error writing the remaining world: %v", err)
+		return 1
+	}
+	if result.StopReason == simulation.Cancelled && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return 124
+	}
+	return exitCode(result)
+}
+
+// kebabToCamelCase lets every flag also be written in kebab-case (e.g. --dot-out for --dotOut)
+func kebabToCamelCase(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	parts := strings.Split(name, "-")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return pflag.NormalizedName(strings.Join(parts, ""))
+}
+
+// exitCode converts the outcome of a simulation into the exit code of the program
+func exitCode(result *simulation.SimulationResult) int {
+	switch result.StopReason {
+	case simulation.SimulationFailed:
+		return 1
+	case simulation.Cancelled:
+		return 130
+	}
+	return 0
+}
+
+// writeMapTo writes the world in the map file format to the given file, or to stdout if no file was specified
+func writeMapTo(world *structs.World, fileName string) error {
+	if fileName == "" {
+		return world.WriteMap(os.Stdout)
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
 	}
-	simulation.InitializeSimulation()
-	simulation.Run()
+	defer file.Close()
+	return world.WriteMap(file)
 }
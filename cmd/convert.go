@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
+	"github.com/spf13/cobra"
+)
+
+var (
+
+	// Used for flags.
+	convertMapFileName    string
+	convertFrom           string
+	convertTo             string
+	convertOutputFileName string
+
+	convertCmd = &cobra.Command{
+		Use:   "convert",
+		Short: "Convert a map file between the text, JSON and YAML formats.",
+		Long:  `Read a map file in one format and write it in another. The formats default to the ones matching the file extensions. Attributes of cities and roads are dropped when converting to the text format.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Exit(convert())
+		},
+	}
+)
+
+func init() {
+	convertCmd.Flags().StringVarP(&convertMapFileName, "mapFileName", "m", "map.txt", "Specify map file name.")
+	convertCmd.Flags().StringVar(&convertFrom, "from", "", "Specify format of the map file (text, json or yaml, defaults to the format matching the file extension).")
+	convertCmd.Flags().StringVar(&convertTo, "to", "", "Specify format to convert the map to (text, json or yaml, defaults to the format matching the output file extension).")
+	convertCmd.Flags().StringVarP(&convertOutputFileName, "output", "o", "", "Specify file to write the converted map to (defaults to stdout).")
+	rootCmd.AddCommand(convertCmd)
+}
+
+// convert converts the map file and returns the exit code of the program
+func convert() int {
+	from, err := mapfile.ResolveMapFormat(convertMapFileName, convertFrom)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the map format: %v", err)
+		return 1
+	}
+	if convertTo == "" && convertOutputFileName == "" {
+		fmt.Fprintf(os.Stderr, "Specify the format to convert to with --to or an output file with a known extension.\n")
+		return 1
+	}
+	to, err := mapfile.ResolveMapFormat(convertOutputFileName, convertTo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the map format: %v", err)
+		return 1
+	}
+
+	in, err := os.Open(convertMapFileName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening the map file: %v\n", err)
+		return 1
+	}
+	defer in.Close()
+
+	out := os.Stdout
+	if convertOutputFileName != "" {
+		out, err = os.Create(convertOutputFileName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating the output file: %v\n", err)
+			return 1
+		}
+		defer out.Close()
+	}
+
+	m, err := mapfile.ConvertMap(in, from, out, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error converting the map: %v", err)
+		return 1
+	}
+	if to == mapfile.TextFormat && m.HasAttributes() {
+		fmt.Fprintf(os.Stderr, "The attributes of the map cannot be written in the text format and were dropped.\n")
+	}
+	return 0
+}
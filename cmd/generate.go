@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
+	"github.com/spf13/cobra"
+)
+
+var (
+
+	// Used for flags.
+	generateTopologyName   string
+	generateWidth          int
+	generateHeight         int
+	generateCities         int
+	generateDropout        float64
+	generateNamingName     string
+	generateSeed           int64
+	generateFormat         string
+	generateOutputFileName string
+
+	generateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a random map.",
+		Long:  `Generate a map whose roads all lead both ways, with the cities laid out on a grid so that every road leads one step in its direction. The map can be a full grid, a grid with roads left out, a random spanning tree of a grid, a grid wrapped around like a torus, a chain of cities or a grid whose cities have 1 to 4 random roads.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// without an explicit seed every map is different
+			if !cmd.Flags().Changed("seed") {
+				generateSeed = time.Now().UnixNano()
+			}
+			os.Exit(generate())
+		},
+	}
+)
+
+func init() {
+	generateCmd.Flags().StringVarP(&generateTopologyName, "topology", "t", "grid", "Specify topology of the map (grid, dropout, tree, torus, chain or random).")
+	generateCmd.Flags().IntVar(&generateWidth, "width", 0, "Specify width of the grid the cities are laid out on (derived from the number of cities if not set).")
+	generateCmd.Flags().IntVar(&generateHeight, "height", 0, "Specify height of the grid the cities are laid out on (derived from the number of cities if not set).")
+	generateCmd.Flags().IntVarP(&generateCities, "cities", "n", 0, "Specify number of cities (fills the whole grid if not set).")
+	generateCmd.Flags().Float64Var(&generateDropout, "dropout", 0.3, "Specify probability of every road being left out of a dropout grid.")
+	generateCmd.Flags().StringVar(&generateNamingName, "naming", "coordinates", "Specify how cities are named (coordinates or petnames).")
+	generateCmd.Flags().Int64VarP(&generateSeed, "seed", "s", 0, "Specify seed for the random choices to reproduce a map (defaults to a time-based seed).")
+	generateCmd.Flags().StringVar(&generateFormat, "format", "", "Specify format of the map (text, json or yaml, defaults to the format matching the output file extension).")
+	generateCmd.Flags().StringVarP(&generateOutputFileName, "output", "o", "", "Specify file to write the map to (defaults to stdout).")
+	rootCmd.AddCommand(generateCmd)
+}
+
+// generate generates the map and returns the exit code of the program
+func generate() int {
+	topology, err := mapfile.ParseTopology(generateTopologyName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the topology: %v", err)
+		return 1
+	}
+	naming, err := mapfile.ParseNamingScheme(generateNamingName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the naming scheme: %v", err)
+		return 1
+	}
+	format, err := mapfile.ResolveMapFormat(generateOutputFileName, generateFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the map format: %v", err)
+		return 1
+	}
+
+	m, err := mapfile.GenerateMap(mapfile.GeneratorConfig{
+		Topology: topology,
+		Width:    generateWidth,
+		Height:   generateHeight,
+		Cities:   generateCities,
+		Dropout:  generateDropout,
+		Naming:   naming,
+		Seed:     generateSeed,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating the map: %v", err)
+		return 1
+	}
+
+	out := os.Stdout
+	if generateOutputFileName != "" {
+		out, err = os.Create(generateOutputFileName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating the output file: %v\n", err)
+			return 1
+		}
+		defer out.Close()
+	}
+	if err := mapfile.LoaderFor(format).Write(out, m); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing the map: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
+	"github.com/AleksandarHr/AlienInvasion/structs"
+)
+
+// loadMapInfo loads the map file in the given format and, if requested, repairs it and writes the
+// repaired map to a file in the text format
+func loadMapInfo(fileName string, format string, repair bool, repairedFileName string) (map[string][]string, error) {
+	mapInfo, err := mapfile.LoadMapInfo(fileName, format)
+	if err != nil {
+		return nil, err
+	}
+	if !repair {
+		return mapInfo, nil
+	}
+
+	repairedInfo, changes, err := mapfile.RepairMap(mapInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if repairedFileName == "" {
+		repairedFileName = fileName + ".repaired"
+	}
+	world := structs.CreateWorld()
+	world.InitializeWorld(repairedInfo)
+	file, err := os.Create(repairedFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if err := world.WriteMap(file); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(os.Stderr, "Repaired %s with %d inferred change(s), written to %s:\n", fileName, len(changes), repairedFileName)
+	for _, change := range changes {
+		fmt.Fprintf(os.Stderr, "  %s\n", change.String())
+	}
+	return repairedInfo, nil
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
+	"github.com/AleksandarHr/AlienInvasion/simulation"
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/spf13/cobra"
+)
+
+var (
+
+	// Used for flags.
+	renderMapFileName    string
+	renderMapFormat      string
+	renderEventsFileName string
+	renderIteration      int
+	renderFightResolver  string
+	renderOutputFileName string
+	renderOmitDestroyed  bool
+	renderASCII          bool
+	renderRoot           string
+
+	renderCmd = &cobra.Command{
+		Use:   "render",
+		Short: "Render a map as a Graphviz DOT graph or as ASCII art.",
+		Long:  `Render the world of a map file as a Graphviz DOT graph, with cities as nodes and roads as edges labelled with their direction, or as ASCII art laid out on a grid by following the directions of the roads. Given the events recorded with --events, the world is replayed first and rendered along with its aliens at the end of the recording, or at the requested iteration.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Exit(render(cmd.Flags().Changed("at")))
+		},
+	}
+)
+
+func init() {
+	renderCmd.Flags().StringVarP(&renderMapFileName, "mapFileName", "m", "map.txt", "Specify map file name.")
+	renderCmd.Flags().StringVar(&renderMapFormat, "format", "", "Specify map file format (text, json or yaml, defaults to the format matching the file extension).")
+	renderCmd.Flags().StringVarP(&renderEventsFileName, "events", "e", "", "Specify file with the recorded events to replay before rendering.")
+	renderCmd.Flags().IntVar(&renderIteration, "at", 0, "Specify iteration after which to render the replayed world (-1 for right after spawning, defaults to the end of the recording).")
+	renderCmd.Flags().StringVar(&renderFightResolver, "fight", "", "Specify fight resolver of the recorded simulation (defaults to the resolver named in the events, which it must match).")
+	renderCmd.Flags().StringVarP(&renderOutputFileName, "output", "o", "", "Specify file to write the DOT graph to (defaults to stdout).")
+	renderCmd.Flags().BoolVar(&renderOmitDestroyed, "omitDestroyed", false, "Leave destroyed cities out of the graph instead of greying them out.")
+	renderCmd.Flags().BoolVar(&renderASCII, "ascii", false, "Draw the world as ASCII art laid out on a grid instead of a DOT graph.")
+	renderCmd.Flags().StringVar(&renderRoot, "root", "", "Specify city the ASCII layout starts from (defaults to the first city by name).")
+	rootCmd.AddCommand(renderCmd)
+}
+
+// render writes the world as a DOT graph and returns the exit code of the program
+func render(atIteration bool) int {
+	mapInfo, err := mapfile.LoadMapInfo(renderMapFileName, renderMapFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading the map: %v", err)
+		return 1
+	}
+
+	world := structs.CreateWorld()
+	world.InitializeWorld(mapInfo)
+	exitCode := 0
+	if renderEventsFileName != "" {
+		var fightResolver structs.FightResolver
+		if renderFightResolver != "" {
+			if fightResolver, err = structs.ParseFightResolver(renderFightResolver); err != nil {
+				fmt.Fprintf(os.Stderr, "Error parsing the fight resolver: %v", err)
+				return 1
+			}
+		}
+		eventsFile, err := os.Open(renderEventsFileName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening the events file: %v", err)
+			return 1
+		}
+		defer eventsFile.Close()
+
+		var untilIteration *int
+		if atIteration {
+			untilIteration = &renderIteration
+		}
+		replayer, err := simulation.ReplayEvents(mapInfo, fightResolver, eventsFile, untilIteration)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v", err)
+			fmt.Fprintf(os.Stderr, "Rendering the world as of iteration %d, before the problem.\n", replayer.Iteration())
+			exitCode = 1
+		}
+		world = replayer.World()
+	}
+
+	out := os.Stdout
+	if renderOutputFileName != "" {
+		out, err = os.Create(renderOutputFileName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating the output file: %v\n", err)
+			return 1
+		}
+		defer out.Close()
+	}
+	if renderASCII {
+		err = writeASCII(world, out)
+	} else {
+		err = world.WriteDOT(out, structs.DOTOptions{OmitDestroyed: renderOmitDestroyed})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error rendering the world: %v\n", err)
+		return 1
+	}
+	return exitCode
+}
+
+// writeASCII lays the world out on a grid and draws it, reporting the geometry which does not fit the grid on stderr
+func writeASCII(world *structs.World, out *os.File) error {
+	layout, err := world.Layout(renderRoot)
+	if err != nil {
+		return err
+	}
+	for _, conflict := range layout.Conflicts {
+		fmt.Fprintf(os.Stderr, "%s\n", conflict.String())
+	}
+	return world.WriteASCII(out, layout)
+}
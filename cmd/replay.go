@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
+	"github.com/AleksandarHr/AlienInvasion/simulation"
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/spf13/cobra"
+)
+
+var (
+
+	// Used for flags.
+	replayMapFileName    string
+	replayMapFormat      string
+	replayEventsFileName string
+	replayIteration      int
+	replayOutputFileName string
+	replayFightResolver  string
+
+	replayCmd = &cobra.Command{
+		Use:   "replay",
+		Short: "Replay a recorded simulation from its event log.",
+		Long:  `Rebuild the world step by step from a map file and the events recorded with --events, checking that every recorded move was legal and had the recorded consequences. Prints the world at the requested iteration, or at the end of the recording.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Exit(replay(cmd.Flags().Changed("at")))
+		},
+	}
+)
+
+func init() {
+	replayCmd.Flags().StringVarP(&replayMapFileName, "mapFileName", "m", "map.txt", "Specify map file name.")
+	replayCmd.Flags().StringVar(&replayMapFormat, "format", "", "Specify map file format (text, json or yaml, defaults to the format matching the file extension).")
+	replayCmd.Flags().StringVarP(&replayEventsFileName, "events", "e", "", "Specify file with the recorded events.")
+	replayCmd.Flags().IntVar(&replayIteration, "at", 0, "Specify iteration after which to print the world (-1 for right after spawning, defaults to the end of the recording).")
+	replayCmd.Flags().StringVarP(&replayOutputFileName, "output", "o", "", "Specify file to write the replayed world to (defaults to stdout).")
+	replayCmd.Flags().StringVar(&replayFightResolver, "fight", "", "Specify fight resolver of the recorded simulation (defaults to the resolver named in the events, which it must match).")
+	replayCmd.MarkFlagRequired("events")
+	rootCmd.AddCommand(replayCmd)
+}
+
+// replay rebuilds the world from the recorded events and returns 0 if the recording was consistent
+func replay(atIteration bool) int {
+	mapInfo, err := mapfile.LoadMapInfo(replayMapFileName, replayMapFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading the map: %v", err)
+		return 2
+	}
+	var fightResolver structs.FightResolver
+	if replayFightResolver != "" {
+		if fightResolver, err = structs.ParseFightResolver(replayFightResolver); err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing the fight resolver: %v", err)
+			return 2
+		}
+	}
+	eventsFile, err := os.Open(replayEventsFileName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening the events file: %v", err)
+		return 2
+	}
+	defer eventsFile.Close()
+
+	var untilIteration *int
+	if atIteration {
+		untilIteration = &replayIteration
+	}
+	replayer, replayErr := simulation.ReplayEvents(mapInfo, fightResolver, eventsFile, untilIteration)
+	if replayErr != nil {
+		fmt.Fprintf(os.Stderr, "%v", replayErr)
+		fmt.Fprintf(os.Stderr, "World as of iteration %d, before the problem:\n", replayer.Iteration())
+	}
+
+	world := replayer.World()
+	aliens, _ := world.GetAllAliens()
+	for _, alien := range aliens {
+		if free, _ := world.IsAlienFree(alien); free {
+			fmt.Fprintf(os.Stderr, "Alien %d (%s) is in %s.\n", alien.ID, alien.Name, alien.Location.Name)
+		} else {
+			fmt.Fprintf(os.Stderr, "Alien %d (%s) is TRAPPED in %s.\n", alien.ID, alien.Name, alien.Location.Name)
+		}
+	}
+	if err := writeMapTo(world, replayOutputFileName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing the replayed world: %v", err)
+		return 2
+	}
+
+	if replayErr != nil {
+		return 1
+	}
+	return 0
+}
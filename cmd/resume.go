@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AleksandarHr/AlienInvasion/simulation"
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/spf13/cobra"
+)
+
+var (
+
+	// Used for flags.
+	resumeCheckpointFileName string
+	resumeCheckpointEvery    int
+	resumeOutputFileName     string
+	resumeEventsFileName     string
+	resumeDOTFileName        string
+	resumeDOTIterations      []int
+	resumeDOTOmitDestroyed   bool
+	resumeTimeout            time.Duration
+
+	resumeCmd = &cobra.Command{
+		Use:   "resume",
+		Short: "Resume a simulation from a checkpoint.",
+		Long:  `Continue a simulation from a checkpoint saved with --checkpoint. The resumed run ends exactly like the original run would have.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Exit(resume())
+		},
+	}
+)
+
+func init() {
+	resumeCmd.Flags().StringVarP(&resumeCheckpointFileName, "checkpoint", "c", "", "Specify checkpoint file to resume from, which further checkpoints are saved to.")
+	resumeCmd.Flags().IntVar(&resumeCheckpointEvery, "checkpointEvery", 0, "Specify number of iterations between checkpoints (no periodic checkpoints if 0).")
+	resumeCmd.Flags().StringVarP(&resumeOutputFileName, "output", "o", "", "Specify file to write the remaining world to (defaults to stdout).")
+	resumeCmd.Flags().StringVar(&resumeEventsFileName, "events", "", "Specify file to write the events of the resumed simulation to as newline-delimited JSON.")
+	resumeCmd.Flags().StringVar(&resumeDOTFileName, "dotOut", "", "Specify file to render the remaining world to as a Graphviz DOT graph.")
+	resumeCmd.Flags().IntSliceVar(&resumeDOTIterations, "dotAt", nil, "Specify iterations after which to also render the world, each to the DOT file name suffixed with the iteration.")
+	resumeCmd.Flags().BoolVar(&resumeDOTOmitDestroyed, "dotOmitDestroyed", false, "Leave destroyed cities out of the DOT graph instead of greying them out (kept from the checkpoint if it was set).")
+	resumeCmd.Flags().DurationVar(&resumeTimeout, "timeout", 0, "Specify wall-clock time after which the resumed simulation is cancelled (e.g. 90s or 10m, no limit if 0).")
+	resumeCmd.MarkFlagRequired("checkpoint")
+	rootCmd.AddCommand(resumeCmd)
+}
+
+// resume continues the simulation from the checkpoint and returns the exit code of the program
+func resume() int {
+	checkpointFile, err := os.Open(resumeCheckpointFileName)
+	if err != nil {
+		fmt.Printf("Error opening the checkpoint: %v", err)
+		return 1
+	}
+	defer checkpointFile.Close()
+
+	config := simulation.Config{
+		CheckpointEvery:    resumeCheckpointEvery,
+		CheckpointFileName: resumeCheckpointFileName,
+		DOTFileName:        resumeDOTFileName,
+		DOTIterations:      resumeDOTIterations,
+		DOTOptions:         structs.DOTOptions{OmitDestroyed: resumeDOTOmitDestroyed},
+	}
+	if resumeEventsFileName != "" {
+		eventsFile, err := os.Create(resumeEventsFileName)
+		if err != nil {
+			fmt.Printf("Error creating the events file: %v", err)
+			return 1
+		}
+		defer eventsFile.Close()
+		eventsWriter := bufio.NewWriter(eventsFile)
+		defer eventsWriter.Flush()
+		config.EventWriter = eventsWriter
+	}
+
+	simulation, err := simulation.ResumeSimulation(checkpointFile, config)
+	if err != nil {
+		fmt.Printf("Error resuming the simulation: %v", err)
+		return 1
+	}
+	checkpointFile.Close()
+	return runUntilDone(simulation, resumeOutputFileName, resumeTimeout)
+}
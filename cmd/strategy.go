@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AleksandarHr/AlienInvasion/simulation"
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/AleksandarHr/AlienInvasion/utils"
+)
+
+// parseStrategies parses the values of the --strategy flag. A plain strategy name sets the strategy
+// of every alien, while ID=name sets the strategy of a single alien
+func parseStrategies(specs []string) (structs.MovementStrategy, map[int]structs.MovementStrategy, error) {
+	var movementStrategy structs.MovementStrategy
+	alienStrategies := make(map[int]structs.MovementStrategy)
+
+	for _, spec := range specs {
+		alienID, name, perAlien := strings.Cut(spec, "=")
+		if !perAlien {
+			name = spec
+		}
+		strategy, err := structs.ParseMovementStrategy(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !perAlien {
+			movementStrategy = strategy
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(alienID))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid alien ID in strategy %q.\n", spec)
+		}
+		alienStrategies[id] = strategy
+	}
+	return movementStrategy, alienStrategies, nil
+}
+
+// parseSpawnStrategy parses the value of the --spawn flag, unless a placement file was given
+// in which case every alien spawns in the city it is placed in
+func parseSpawnStrategy(name string, placementFileName string) (structs.SpawnStrategy, error) {
+	if placementFileName == "" {
+		return structs.ParseSpawnStrategy(name)
+	}
+	placements, err := utils.ParsePlacementFile(placementFileName)
+	if err != nil {
+		return nil, err
+	}
+	return structs.PlacementSpawnStrategy{Placements: placements}, nil
+}
+
+// parseWaves parses the values of the --waves and --maxAliens flags, returning nil if there are no waves
+func parseWaves(spec string, maxAliens int) (*simulation.WaveSchedule, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	return simulation.ParseWaveSchedule(spec, maxAliens)
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/AleksandarHr/AlienInvasion/simulation"
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/AleksandarHr/AlienInvasion/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+
+	// Used for flags.
+	sweepAliensCounts   string
+	sweepMaxIterations  string
+	sweepMapFileNames   []string
+	sweepMapFormat      string
+	sweepRuns           int
+	sweepWorkers        int
+	sweepSeed           int64
+	sweepReportFormat   string
+	sweepOutputFileName string
+	sweepStrategies     []string
+	sweepFightResolver  string
+	sweepMovementMode   string
+	sweepSpawnStrategy  string
+	sweepPlacement      string
+	sweepWaves          string
+	sweepMaxAliens      int
+
+	sweepCmd = &cobra.Command{
+		Use:   "sweep",
+		Short: "Run batches of simulations over ranges of parameters.",
+		Long:  `Run a batch of simulations for every combination of map, number of aliens and maximum iterations, and report the distribution of the outcomes of each combination as a grid.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !cmd.Flags().Changed("seed") {
+				sweepSeed = time.Now().UnixNano()
+			}
+			os.Exit(sweep())
+		},
+	}
+)
+
+func init() {
+	sweepCmd.Flags().StringVarP(&sweepAliensCounts, "alienCount", "N", "5", "Specify numbers of aliens as a list (5,10,20) or a range (start:end[:step]).")
+	sweepCmd.Flags().StringSliceVarP(&sweepMapFileNames, "mapFileName", "m", []string{"map.txt"}, "Specify map file names as a list.")
+	sweepCmd.Flags().StringVar(&sweepMapFormat, "format", "", "Specify map file format (text, json or yaml, defaults to the format matching the extension of each file).")
+	sweepCmd.Flags().StringVarP(&sweepMaxIterations, "iterations", "i", "10000", "Specify numbers of maximum iterations as a list (100,1000) or a range (start:end[:step]).")
+	sweepCmd.Flags().IntVarP(&sweepRuns, "runs", "k", 100, "Specify number of simulations to run for every combination.")
+	sweepCmd.Flags().IntVarP(&sweepWorkers, "workers", "w", 0, "Specify number of simulations to run in parallel (defaults to the number of CPUs).")
+	sweepCmd.Flags().Int64VarP(&sweepSeed, "seed", "s", 0, "Specify base seed shared by every combination (defaults to a time-based seed).")
+	sweepCmd.Flags().StringVarP(&sweepReportFormat, "report", "r", "csv", "Specify report format (csv or json).")
+	sweepCmd.Flags().StringVarP(&sweepOutputFileName, "output", "o", "", "Specify file to write the report to (defaults to stdout).")
+	sweepCmd.Flags().StringVar(&sweepSpawnStrategy, "spawn", "uniform", "Specify spawn strategy.")
+	sweepCmd.Flags().StringVar(&sweepPlacement, "placement", "", "Specify file placing every alien in a city (overrides spawn).")
+	sweepCmd.Flags().StringVar(&sweepWaves, "waves", "", "Specify waves of aliens spawned during the simulations (e.g. 5@100/100).")
+	sweepCmd.Flags().IntVar(&sweepMaxAliens, "maxAliens", 0, "Specify total number of aliens after which the waves stop.")
+	sweepCmd.Flags().StringArrayVar(&sweepStrategies, "strategy", nil, "Specify movement strategy, or ID=strategy for a single alien. Can be repeated.")
+	sweepCmd.Flags().StringVar(&sweepFightResolver, "fight", "mutual-destruction", "Specify fight resolver.")
+	sweepCmd.Flags().StringVar(&sweepMovementMode, "movement", "sequential", "Specify movement mode (sequential or simultaneous).")
+	rootCmd.AddCommand(sweepCmd)
+}
+
+// sweep runs the batches of simulations and writes the grid, returning the exit code of the program
+func sweep() int {
+	if sweepReportFormat != "json" && sweepReportFormat != "csv" {
+		fmt.Fprintf(os.Stderr, "Unknown report format %q.\n", sweepReportFormat)
+		return 1
+	}
+	if sweepRuns < 1 {
+		fmt.Fprintf(os.Stderr, "Invalid number of runs %d, expected at least 1.\n", sweepRuns)
+		return 1
+	}
+	aliensCounts, err := utils.ParseNonNegativeIntRange(sweepAliensCounts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the numbers of aliens: %v", err)
+		return 1
+	}
+	maxIterations, err := utils.ParseNonNegativeIntRange(sweepMaxIterations)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the numbers of maximum iterations: %v", err)
+		return 1
+	}
+
+	spawnStrategy, err := parseSpawnStrategy(sweepSpawnStrategy, sweepPlacement)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the spawn strategy: %v", err)
+		return 1
+	}
+	waves, err := parseWaves(sweepWaves, sweepMaxAliens)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the waves: %v", err)
+		return 1
+	}
+	movementStrategy, alienStrategies, err := parseStrategies(sweepStrategies)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the movement strategies: %v", err)
+		return 1
+	}
+	fightResolver, err := structs.ParseFightResolver(sweepFightResolver)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the fight resolver: %v", err)
+		return 1
+	}
+	movementMode, err := simulation.ParseMovementMode(sweepMovementMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing the movement mode: %v", err)
+		return 1
+	}
+
+	cells, err := simulation.RunSweep(simulation.SweepConfig{
+		MapFileNames:     sweepMapFileNames,
+		MapFormat:        sweepMapFormat,
+		AliensCounts:     aliensCounts,
+		MaxIterations:    maxIterations,
+		Runs:             sweepRuns,
+		Workers:          sweepWorkers,
+		Seed:             sweepSeed,
+		SpawnStrategy:    spawnStrategy,
+		Waves:            waves,
+		MovementStrategy: movementStrategy,
+		AlienStrategies:  alienStrategies,
+		FightResolver:    fightResolver,
+		Movement:         movementMode,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running the sweep: %v", err)
+		return 1
+	}
+
+	err = writeReport(sweepOutputFileName, func(out io.Writer) error {
+		if sweepReportFormat == "json" {
+			return writeJSON(out, cells)
+		}
+		return writeSweepCSV(out, cells)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing the report: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// writeSweepCSV writes the sweep as a grid with one row per combination
+func writeSweepCSV(out io.Writer, cells []simulation.SweepCell) error {
+	writer := csv.NewWriter(out)
+	formatFloat := func(value float64) string { return strconv.FormatFloat(value, 'g', -1, 64) }
+	// a batch runs every simulation to completion, so these are all the reasons it can stop for
+	stopReasons := []simulation.StopReason{
+		simulation.MaxIterationsReached,
+		simulation.AllAliensDead,
+		simulation.AllAliensTrapped,
+		simulation.NoCitiesLeft,
+		simulation.SimulationFailed,
+	}
+	metrics := []string{"iterations", "survivingAliens", "destroyedCities"}
+
+	header := []string{"map", "alienCount", "iterations", "runs"}
+	for _, reason := range stopReasons {
+		header = append(header, reason.String())
+	}
+	for _, metric := range metrics {
+		header = append(header, metric+"Mean", metric+"P50", metric+"P90", metric+"P99")
+	}
+	writer.Write(header)
+
+	for _, cell := range cells {
+		row := []string{cell.MapFileName, strconv.Itoa(cell.AliensCount), strconv.Itoa(cell.MaxIterations), strconv.Itoa(cell.Runs)}
+		for _, reason := range stopReasons {
+			row = append(row, strconv.Itoa(cell.StopReasons[reason.String()]))
+		}
+		for _, d := range []simulation.Distribution{cell.Iterations, cell.SurvivingAliens, cell.DestroyedCities} {
+			row = append(row, formatFloat(d.Mean), formatFloat(d.P50), formatFloat(d.P90), formatFloat(d.P99))
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// enterRawMode switches the terminal to read keys as soon as they are pressed, without echoing
+// them, and returns the function restoring the previous settings. It relies on stty, so keys
+// have to be followed by Enter where stty is not available
+func enterRawMode() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("cbreak", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() {
+		stty(strings.TrimSpace(saved))
+	}, nil
+}
+
+// stty runs stty with the given arguments on the terminal of the standard input
+func stty(args ...string) (string, error) {
+	command := exec.Command("stty", args...)
+	command.Stdin = os.Stdin
+	out, err := command.Output()
+	return string(out), err
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
+	"github.com/spf13/cobra"
+)
+
+var (
+
+	// Used for flags.
+	validateMapFileName  string
+	validateReportFormat string
+	validateMapFormat    string
+
+	validateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Check a map file for problems.",
+		Long:  `Check a map file for malformed roads, unknown directions, duplicate cities and roads, roads to undeclared cities, one-way roads and illegal city names. Exits with a non-zero code if any problem is found.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Exit(validate())
+		},
+	}
+)
+
+func init() {
+	validateCmd.Flags().StringVarP(&validateMapFileName, "mapFileName", "m", "map.txt", "Specify map file name.")
+	validateCmd.Flags().StringVar(&validateMapFormat, "format", "", "Specify map file format (text, json or yaml, defaults to the format matching the file extension).")
+	validateCmd.Flags().StringVarP(&validateReportFormat, "report", "r", "text", "Specify report format (text or json).")
+	rootCmd.AddCommand(validateCmd)
+}
+
+// validate checks the map file and returns 0 if it passed, 1 if it has problems and 2 if it cannot be checked
+func validate() int {
+	if validateReportFormat != "text" && validateReportFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown report format %q.\n", validateReportFormat)
+		return 2
+	}
+
+	report, err := mapfile.ValidateMapFile(validateMapFileName, validateMapFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error validating the map file: %v\n", err)
+		return 2
+	}
+
+	if validateReportFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing the report: %v\n", err)
+			return 2
+		}
+	} else {
+		for _, problem := range report.Problems {
+			fmt.Println(problem.String())
+		}
+		if report.Passed {
+			fmt.Printf("%s: OK\n", report.File)
+		} else {
+			fmt.Printf("%s: %d problem(s) found\n", report.File, len(report.Problems))
+		}
+	}
+
+	if !report.Passed {
+		return 1
+	}
+	return 0
+}
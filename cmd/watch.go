@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
+	"github.com/AleksandarHr/AlienInvasion/simulation"
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/spf13/cobra"
+)
+
+var (
+
+	// Used for flags.
+	watchAliensCount   int
+	watchMapFileName   string
+	watchMapFormat     string
+	watchMaxIterations int
+	watchSeed          int64
+	watchSpawnStrategy string
+	watchPlacement     string
+	watchWaves         string
+	watchMaxAliens     int
+	watchStrategies    []string
+	watchFightResolver string
+	watchMovementMode  string
+	watchDelay         time.Duration
+	watchRoot          string
+
+	watchCmd = &cobra.Command{
+		Use:   "watch",
+		Short: "Watch a simulation live in the terminal.",
+		Long:  `Run a simulation in the terminal, showing the world as a grid or as a list of cities and roads, a feed of the latest events and counters of the aliens and cities. The simulation can be paused, stepped one iteration or one alien move at a time, sped up and slowed down.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !cmd.Flags().Changed("seed") {
+				watchSeed = time.Now().UnixNano()
+			}
+			os.Exit(watch())
+		},
+	}
+)
+
+func init() {
+	watchCmd.Flags().IntVarP(&watchAliensCount, "alienCount", "N", 5, "Specify number of aliens.")
+	watchCmd.Flags().StringVarP(&watchMapFileName, "mapFileName", "m", "map.txt", "Specify map file name.")
+	watchCmd.Flags().StringVar(&watchMapFormat, "format", "", "Specify map file format (text, json or yaml, defaults to the format matching the file extension).")
+	watchCmd.Flags().IntVarP(&watchMaxIterations, "iterations", "i", 10000, "Specify number of maximum iterations.")
+	watchCmd.Flags().Int64VarP(&watchSeed, "seed", "s", 0, "Specify seed for the random choices to reproduce a run (defaults to a time-based seed).")
+	watchCmd.Flags().StringVar(&watchSpawnStrategy, "spawn", "uniform", "Specify spawn strategy.")
+	watchCmd.Flags().StringVar(&watchPlacement, "placement", "", "Specify file placing every alien in a city (overrides spawn).")
+	watchCmd.Flags().StringVar(&watchWaves, "waves", "", "Specify waves of aliens spawned during the simulation (e.g. 5@100/100).")
+	watchCmd.Flags().IntVar(&watchMaxAliens, "maxAliens", 0, "Specify total number of aliens after which the waves stop.")
+	watchCmd.Flags().StringArrayVar(&watchStrategies, "strategy", nil, "Specify movement strategy, or ID=strategy for a single alien. Can be repeated.")
+	watchCmd.Flags().StringVar(&watchFightResolver, "fight", "mutual-destruction", "Specify fight resolver.")
+	watchCmd.Flags().StringVar(&watchMovementMode, "movement", "sequential", "Specify movement mode (sequential or simultaneous).")
+	watchCmd.Flags().DurationVar(&watchDelay, "delay", 500*time.Millisecond, "Specify time between iterations while running.")
+	watchCmd.Flags().StringVar(&watchRoot, "root", "", "Specify city the grid layout starts from (defaults to the first city by name).")
+	rootCmd.AddCommand(watchCmd)
+}
+
+// number of events shown in the event feed
+const watchFeedSize = 10
+
+// bounds of the time between iterations
+const (
+	watchMinDelay = 10 * time.Millisecond
+	watchMaxDelay = 5 * time.Second
+)
+
+// watch runs the simulation in the terminal and returns the exit code of the program
+func watch() int {
+	mapInfo, err := mapfile.LoadMapInfo(watchMapFileName, watchMapFormat)
+	if err != nil {
+		fmt.Printf("Error loading the map: %v", err)
+		return 1
+	}
+	spawnStrategy, err := parseSpawnStrategy(watchSpawnStrategy, watchPlacement)
+	if err != nil {
+		fmt.Printf("Error parsing the spawn strategy: %v", err)
+		return 1
+	}
+	waves, err := parseWaves(watchWaves, watchMaxAliens)
+	if err != nil {
+		fmt.Printf("Error parsing the waves: %v", err)
+		return 1
+	}
+	movementStrategy, alienStrategies, err := parseStrategies(watchStrategies)
+	if err != nil {
+		fmt.Printf("Error parsing the movement strategies: %v", err)
+		return 1
+	}
+	fightResolver, err := structs.ParseFightResolver(watchFightResolver)
+	if err != nil {
+		fmt.Printf("Error parsing the fight resolver: %v", err)
+		return 1
+	}
+	movementMode, err := simulation.ParseMovementMode(watchMovementMode)
+	if err != nil {
+		fmt.Printf("Error parsing the movement mode: %v", err)
+		return 1
+	}
+
+	// the grid is laid out once from the whole map, so that cities keep their place as they are destroyed
+	mapWorld := structs.CreateWorld()
+	mapWorld.InitializeWorld(mapInfo)
+	layout, err := mapWorld.Layout(watchRoot)
+	if err != nil {
+		fmt.Printf("Error laying out the map: %v", err)
+		return 1
+	}
+
+	feed := &eventFeed{}
+	sim, err := simulation.CreateSimulation(simulation.Config{
+		AliensCount:      watchAliensCount,
+		SpawnStrategy:    spawnStrategy,
+		Waves:            waves,
+		MapInfo:          mapInfo,
+		MaxIterations:    watchMaxIterations,
+		Seed:             watchSeed,
+		MovementStrategy: movementStrategy,
+		AlienStrategies:  alienStrategies,
+		FightResolver:    fightResolver,
+		Movement:         movementMode,
+		Silent:           true,
+	})
+	if err != nil {
+		fmt.Printf("Error creating a simulation: %v", err)
+		return 1
+	}
+	sim.AddObserver(feed)
+	if err := sim.InitializeSimulation(); err != nil {
+		fmt.Printf("Error initializing the simulation: %v", err)
+		return 1
+	}
+
+	restore, err := enterRawMode()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to read single key presses, follow every key with Enter: %v\n", err)
+		restore = func() {}
+	}
+	// hide the cursor while watching
+	fmt.Print("\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h")
+		restore()
+	}()
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	keys := make(chan byte)
+	go func() {
+		key := make([]byte, 1)
+		for {
+			if _, err := os.Stdin.Read(key); err != nil {
+				close(keys)
+				return
+			}
+			keys <- key[0]
+		}
+	}()
+
+	view := watchView{sim: sim, layout: layout, feed: feed, delay: watchDelay}
+	for {
+		view.draw()
+
+		var tick <-chan time.Time
+		if !view.paused && !sim.Done() {
+			tick = time.After(view.delay)
+		}
+		select {
+		case <-tick:
+			sim.Step()
+		case <-interrupts:
+			return exitCode(sim.Result())
+		case key, ok := <-keys:
+			if !ok || key == 'q' {
+				return exitCode(sim.Result())
+			}
+			view.handleKey(key)
+		}
+	}
+}
+
+// watchView holds the state of the terminal UI
+type watchView struct {
+	sim    *simulation.Simulation
+	layout *structs.Layout
+	feed   *eventFeed
+
+	// time between iterations while running, and whether the simulation is paused
+	delay  time.Duration
+	paused bool
+
+	// whether the world is shown as a list of cities and roads instead of a grid
+	graph bool
+}
+
+// handleKey acts on a key pressed by the user
+func (view *watchView) handleKey(key byte) {
+	switch key {
+	case ' ', 'p':
+		view.paused = !view.paused
+	case 'n':
+		view.paused = true
+		view.sim.Step()
+	case 'm':
+		view.paused = true
+		view.sim.StepAlien()
+	case '+', '=':
+		if view.delay /= 2; view.delay < watchMinDelay {
+			view.delay = watchMinDelay
+		}
+	case '-', '_':
+		if view.delay *= 2; view.delay > watchMaxDelay {
+			view.delay = watchMaxDelay
+		}
+	case 'v':
+		view.graph = !view.graph
+	}
+}
+
+// draw clears the terminal and shows the state of the simulation
+func (view *watchView) draw() {
+	world := view.sim.World()
+	var screen strings.Builder
+	screen.WriteString("\x1b[H\x1b[2J")
+
+	state := fmt.Sprintf("running, %v per iteration", view.delay)
+	if view.sim.Done() {
+		state = "ended: " + view.sim.Result().StopReason.String()
+	} else if view.paused {
+		state = "paused"
+	}
+	fmt.Fprintf(&screen, "Alien invasion - iteration %d - %s\n", view.sim.Iteration(), state)
+
+	aliens, _ := world.GetAllAliens()
+	freeAliens, _ := world.GetFreeAliens()
+	cities, _ := world.GetAllCities()
+	fmt.Fprintf(&screen, "Aliens: %d alive, %d free, %d trapped    Cities: %d remaining, %d destroyed\n\n",
+		len(aliens), len(freeAliens), len(aliens)-len(freeAliens), len(cities), len(world.GetDestroyedCities()))
+
+	if view.graph {
+		writeCityList(&screen, world)
+	} else {
+		world.WriteASCII(&screen, view.layout)
+		if len(view.layout.Conflicts) > 0 {
+			fmt.Fprintf(&screen, "(%d roads or cities do not fit the grid, see the graph view)\n", len(view.layout.Conflicts))
+		}
+	}
+
+	screen.WriteString("\nEvents:\n")
+	for _, line := range view.feed.lines {
+		screen.WriteString("  " + line + "\n")
+	}
+	screen.WriteString("\n[space] pause/resume  [n] step iteration  [m] step alien  [+/-] speed  [v] grid/graph  [q] quit\n")
+	os.Stdout.WriteString(screen.String())
+}
+
+// writeCityList writes every remaining city along with its aliens and roads, followed by the destroyed cities
+func writeCityList(screen *strings.Builder, world *structs.World) {
+	cities, _ := world.GetAllCities()
+	for _, city := range cities {
+		screen.WriteString(city.Name)
+		for _, alien := range world.GetAliensInCity(city.Name) {
+			if free, _ := world.IsAlienFree(alien); free {
+				fmt.Fprintf(screen, " #%d", alien.ID)
+			} else {
+				fmt.Fprintf(screen, " #%d!", alien.ID)
+			}
+		}
+		screen.WriteString(":")
+		for _, dir := range []structs.Direction{structs.North, structs.East, structs.South, structs.West} {
+			if neighbour := city.Neighbours[dir]; neighbour != nil {
+				fmt.Fprintf(screen, " %s=%s", dir.MapKeyword(), neighbour.Name)
+			}
+		}
+		screen.WriteString("\n")
+	}
+	if destroyed := world.GetDestroyedCities(); len(destroyed) > 0 {
+		fmt.Fprintf(screen, "Destroyed: %s\n", strings.Join(destroyed, ", "))
+	}
+}
+
+// eventFeed keeps the latest events of the simulation it observes
+type eventFeed struct {
+	simulation.BaseObserver
+
+	// descriptions of the latest events, oldest first
+	lines []string
+}
+
+// add adds the description of an event to the feed, dropping the oldest one if the feed is full
+func (feed *eventFeed) add(event structs.Event) {
+	feed.lines = append(feed.lines, describeEvent(event))
+	if len(feed.lines) > watchFeedSize {
+		feed.lines = feed.lines[1:]
+	}
+}
+
+func (feed *eventFeed) OnSpawn(event structs.Event)         { feed.add(event) }
+func (feed *eventFeed) OnMove(event structs.Event)          { feed.add(event) }
+func (feed *eventFeed) OnFight(event structs.Event)         { feed.add(event) }
+func (feed *eventFeed) OnCityDestroyed(event structs.Event) { feed.add(event) }
+func (feed *eventFeed) OnRoadRemoved(event structs.Event)   { feed.add(event) }
+func (feed *eventFeed) OnTrapped(event structs.Event)       { feed.add(event) }
+
+func (feed *eventFeed) OnEnd(result *simulation.SimulationResult) {
+	feed.add(structs.Event{Type: structs.SimulationEnded, Iteration: result.Iterations, StopReason: result.StopReason.String()})
+}
+
+// describeEvent returns a short description of the event
+func describeEvent(event structs.Event) string {
+	description := ""
+	switch event.Type {
+	case structs.AlienSpawned:
+		description = fmt.Sprintf("alien %d spawned in %s", event.AlienIDs[0], event.City)
+	case structs.AlienMoved:
+		description = fmt.Sprintf("alien %d moved %s from %s to %s", event.AlienIDs[0], event.Direction, event.From, event.City)
+	case structs.AlienTrapped:
+		description = fmt.Sprintf("alien %d is trapped in %s", event.AlienIDs[0], event.City)
+	case structs.FightOccurred:
+		place := "in " + event.City
+		if event.From != "" {
+			place = "on the road between " + event.From + " and " + event.City
+		}
+		description = fmt.Sprintf("aliens %v fought %s, survivors %v", event.AlienIDs, place, event.Survivors)
+	case structs.CityDestroyed:
+		description = fmt.Sprintf("%s was destroyed", event.City)
+	case structs.RoadRemoved:
+		description = fmt.Sprintf("road %s=%s of %s was removed", event.Direction, event.City, event.From)
+	case structs.SimulationEnded:
+		description = fmt.Sprintf("simulation ended: %s", event.StopReason)
+	default:
+		description = event.Type.String()
+	}
+	return fmt.Sprintf("[%d] %s", event.Iteration, description)
+}
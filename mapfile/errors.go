@@ -0,0 +1,92 @@
+package mapfile
+
+import (
+	"fmt"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+)
+
+// error triggered when an inferred or declared road clashes with an existing road in the same direction
+type RepairConflictError struct {
+	city      string
+	direction structs.Direction
+	existing  string
+	inferred  string
+
+	// whether the clashing road is declared by the map rather than inferred
+	declared bool
+}
+
+func (err *RepairConflictError) Error() string {
+	if err.declared {
+		return fmt.Sprintf("Cannot repair %s: it has both road %s=%s and road %s=%s.\n",
+			err.city, err.direction.MapKeyword(), err.existing, err.direction.MapKeyword(), err.inferred)
+	}
+	return fmt.Sprintf("Cannot infer road %s=%s for %s: it already has road %s=%s.\n",
+		err.direction.MapKeyword(), err.inferred, err.city, err.direction.MapKeyword(), err.existing)
+}
+
+// error triggered when a road cannot be understood well enough to be repaired
+type MalformedRoadError struct {
+	city string
+	road string
+}
+
+func (err *MalformedRoadError) Error() string {
+	return fmt.Sprintf("Cannot repair malformed road %q of %s.\n", err.road, err.city)
+}
+
+// error triggered when parsing an unknown map format
+type InvalidMapFormatError struct {
+	name string
+}
+
+func (err *InvalidMapFormatError) Error() string {
+	return fmt.Sprintf("Unknown map format %q, expected text, json or yaml.\n", err.name)
+}
+
+// error triggered when a map cannot be loaded
+type InvalidMapError struct {
+	city   string
+	reason string
+
+	// index of the city in the map from 1, 0 if unknown
+	cityIndex int
+}
+
+func (err *InvalidMapError) Error() string {
+	if err.cityIndex > 0 {
+		return fmt.Sprintf("Invalid map, city #%d: %s.\n", err.cityIndex, err.reason)
+	}
+	if err.city == "" {
+		return fmt.Sprintf("Invalid map: %s.\n", err.reason)
+	}
+	return fmt.Sprintf("Invalid map, city %s: %s.\n", err.city, err.reason)
+}
+
+// error triggered when parsing an unknown topology of generated maps
+type InvalidTopologyError struct {
+	name string
+}
+
+func (err *InvalidTopologyError) Error() string {
+	return fmt.Sprintf("Unknown topology %q, expected grid, dropout, tree, torus, chain or random.\n", err.name)
+}
+
+// error triggered when parsing an unknown naming scheme of generated cities
+type InvalidNamingSchemeError struct {
+	name string
+}
+
+func (err *InvalidNamingSchemeError) Error() string {
+	return fmt.Sprintf("Unknown naming scheme %q, expected coordinates or petnames.\n", err.name)
+}
+
+// error triggered when a map cannot be generated with the given parameters
+type InvalidGeneratorConfigError struct {
+	reason string
+}
+
+func (err *InvalidGeneratorConfigError) Error() string {
+	return fmt.Sprintf("Cannot generate the map: %s.\n", err.reason)
+}
@@ -0,0 +1,422 @@
+package mapfile
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/AleksandarHr/AlienInvasion/utils"
+)
+
+type Topology int64
+
+// enum to represent the shapes of generated maps
+const (
+	GridTopology Topology = iota
+	DropoutTopology
+	TreeTopology
+	TorusTopology
+	ChainTopology
+	RandomTopology
+)
+
+// String returns a representation of the given topology
+func (topology Topology) String() string {
+	switch topology {
+	case GridTopology:
+		return "grid"
+	case DropoutTopology:
+		return "dropout"
+	case TreeTopology:
+		return "tree"
+	case TorusTopology:
+		return "torus"
+	case ChainTopology:
+		return "chain"
+	case RandomTopology:
+		return "random"
+	}
+	return "invalid-topology"
+}
+
+// ParseTopology returns the topology with the given name
+func ParseTopology(name string) (Topology, error) {
+	for _, topology := range []Topology{GridTopology, DropoutTopology, TreeTopology, TorusTopology, ChainTopology, RandomTopology} {
+		if topology.String() == name {
+			return topology, nil
+		}
+	}
+	return GridTopology, &InvalidTopologyError{name: name}
+}
+
+type NamingScheme int64
+
+// enum to represent the ways generated cities can be named
+const (
+	CoordinateNames NamingScheme = iota
+	PetNames
+)
+
+// String returns a representation of the given naming scheme
+func (naming NamingScheme) String() string {
+	switch naming {
+	case CoordinateNames:
+		return "coordinates"
+	case PetNames:
+		return "petnames"
+	}
+	return "invalid-naming-scheme"
+}
+
+// ParseNamingScheme returns the naming scheme with the given name
+func ParseNamingScheme(name string) (NamingScheme, error) {
+	for _, naming := range []NamingScheme{CoordinateNames, PetNames} {
+		if naming.String() == name {
+			return naming, nil
+		}
+	}
+	return CoordinateNames, &InvalidNamingSchemeError{name: name}
+}
+
+// GeneratorConfig holds the parameters of a generated map
+type GeneratorConfig struct {
+	Topology Topology
+
+	// size of the grid the cities are laid out on, derived from the number of cities if not set
+	Width  int
+	Height int
+
+	// number of cities, filling the grid row by row if it is smaller than the grid
+	Cities int
+
+	// probability of every road of a dropout grid being left out
+	Dropout float64
+
+	Naming NamingScheme
+
+	// seed of the random source; the same config and seed generate the same map
+	Seed int64
+}
+
+// size returns the width and height of the grid and the number of cities laid out on it. A missing
+// side is derived from the number of cities, and both are derived from it if neither is set: a
+// square grid, or a single row for chains
+func (config GeneratorConfig) size() (int, int, int, error) {
+	width, height, cities := config.Width, config.Height, config.Cities
+	if width < 0 || height < 0 || cities < 0 {
+		return 0, 0, 0, &InvalidGeneratorConfigError{reason: "the size of the map cannot be negative"}
+	}
+	switch {
+	case width > 0 && height > 0:
+		if cities == 0 {
+			cities = width * height
+		}
+		if cities > width*height {
+			return 0, 0, 0, &InvalidGeneratorConfigError{reason: "the cities do not fit the grid"}
+		}
+	case cities == 0:
+		return 0, 0, 0, &InvalidGeneratorConfigError{reason: "specify the number of cities or the width and height of the grid"}
+	case width > 0:
+		height = (cities + width - 1) / width
+	case height > 0:
+		width = (cities + height - 1) / height
+	case config.Topology == ChainTopology:
+		width, height = cities, 1
+	default:
+		width = int(math.Ceil(math.Sqrt(float64(cities))))
+		height = (cities + width - 1) / width
+	}
+	return width, height, cities, nil
+}
+
+// generator lays out the cities of a generated map on a grid and connects them
+type generator struct {
+	width  int
+	height int
+	rng    *rand.Rand
+
+	// grid position of every city, and the city in every occupied position
+	positions []structs.GridPosition
+	cities    map[structs.GridPosition]int
+
+	// neighbour of every city in every direction, -1 if there is no road
+	roads [][4]int
+}
+
+// GenerateMap generates a map whose roads all lead both ways, laying the cities out on a grid so
+// that every road leads one step in its direction:
+//   - grid connects every city to its neighbours on the grid
+//   - dropout leaves out every road of the grid with the configured probability
+//   - tree keeps a random spanning tree of the grid, so every city can be reached by a single path
+//   - torus wraps the rows and columns of the grid around, when they hold at least three cities
+//   - chain connects the cities one after the other, snaking through the grid row by row
+//   - random gives every city between 1 and 4 random roads to its neighbours on the grid
+func GenerateMap(config GeneratorConfig) (*Map, error) {
+	width, height, cities, err := config.size()
+	if err != nil {
+		return nil, err
+	}
+	if config.Dropout < 0 || config.Dropout > 1 {
+		return nil, &InvalidGeneratorConfigError{reason: "the dropout probability must be between 0 and 1"}
+	}
+
+	g := &generator{
+		width:     width,
+		height:    height,
+		rng:       rand.New(utils.CreateRandomSource(config.Seed)),
+		positions: make([]structs.GridPosition, cities),
+		cities:    make(map[structs.GridPosition]int, cities),
+		roads:     make([][4]int, cities),
+	}
+	for city := range g.positions {
+		position := structs.GridPosition{X: city % width, Y: city / width}
+		if config.Topology == ChainTopology && position.Y%2 == 1 {
+			// odd rows run westwards
+			position.X = width - 1 - position.X
+		}
+		g.positions[city] = position
+		g.cities[position] = city
+		g.roads[city] = [4]int{-1, -1, -1, -1}
+	}
+
+	switch config.Topology {
+	case GridTopology:
+		g.connectGrid(0)
+	case DropoutTopology:
+		g.connectGrid(config.Dropout)
+	case TreeTopology:
+		g.connectTree()
+	case TorusTopology:
+		g.connectGrid(0)
+		g.wrap()
+	case ChainTopology:
+		for city := 1; city < cities; city++ {
+			g.connect(city-1, g.directionTo(city-1, city), city)
+		}
+	case RandomTopology:
+		g.connectRandomly()
+	default:
+		return nil, &InvalidTopologyError{name: config.Topology.String()}
+	}
+
+	names := g.coordinateNames()
+	if config.Naming == PetNames {
+		names = g.petNames()
+	}
+	m := &Map{Cities: make([]CityEntry, cities)}
+	for city, name := range names {
+		m.Cities[city].Name = name
+		for _, dir := range []structs.Direction{structs.North, structs.East, structs.South, structs.West} {
+			if neighbour := g.roads[city][dir]; neighbour >= 0 {
+				m.Cities[city].Roads = append(m.Cities[city].Roads, RoadEntry{Direction: dir.MapKeyword(), To: names[neighbour]})
+			}
+		}
+	}
+	return m, nil
+}
+
+// neighbour returns the city one step away from the city in the given direction, if there is one
+func (g *generator) neighbour(city int, dir structs.Direction) (int, bool) {
+	position := g.positions[city]
+	switch dir {
+	case structs.North:
+		position.Y--
+	case structs.East:
+		position.X++
+	case structs.South:
+		position.Y++
+	case structs.West:
+		position.X--
+	}
+	neighbour, exists := g.cities[position]
+	return neighbour, exists
+}
+
+// directionTo returns the direction of a neighbouring city on the grid
+func (g *generator) directionTo(city int, neighbour int) structs.Direction {
+	from, to := g.positions[city], g.positions[neighbour]
+	switch {
+	case to.Y < from.Y:
+		return structs.North
+	case to.X > from.X:
+		return structs.East
+	case to.Y > from.Y:
+		return structs.South
+	}
+	return structs.West
+}
+
+// connect adds a road from the city to the neighbour in the given direction, and the road back
+func (g *generator) connect(city int, dir structs.Direction, neighbour int) {
+	g.roads[city][dir] = neighbour
+	g.roads[neighbour][dir.Opposite()] = city
+}
+
+// degree returns the number of roads of the city
+func (g *generator) degree(city int) int {
+	degree := 0
+	for _, neighbour := range g.roads[city] {
+		if neighbour >= 0 {
+			degree++
+		}
+	}
+	return degree
+}
+
+// connectGrid connects every city to its neighbours to the east and south, leaving out every road
+// with the given probability
+func (g *generator) connectGrid(dropout float64) {
+	for city := range g.positions {
+		for _, dir := range []structs.Direction{structs.East, structs.South} {
+			neighbour, exists := g.neighbour(city, dir)
+			if !exists || (dropout > 0 && g.rng.Float64() < dropout) {
+				continue
+			}
+			g.connect(city, dir, neighbour)
+		}
+	}
+}
+
+// connectTree carves a random spanning tree of the grid with a depth-first walk from the first city
+func (g *generator) connectTree() {
+	if len(g.positions) == 0 {
+		return
+	}
+	visited := make([]bool, len(g.positions))
+	visited[0] = true
+	path := []int{0}
+	for len(path) > 0 {
+		city := path[len(path)-1]
+		unvisited := []structs.Direction{}
+		for _, dir := range []structs.Direction{structs.North, structs.East, structs.South, structs.West} {
+			if neighbour, exists := g.neighbour(city, dir); exists && !visited[neighbour] {
+				unvisited = append(unvisited, dir)
+			}
+		}
+		if len(unvisited) == 0 {
+			path = path[:len(path)-1]
+			continue
+		}
+		dir := unvisited[g.rng.Intn(len(unvisited))]
+		neighbour, _ := g.neighbour(city, dir)
+		g.connect(city, dir, neighbour)
+		visited[neighbour] = true
+		path = append(path, neighbour)
+	}
+}
+
+// wrap connects the ends of every row and column of the grid holding at least three cities. Shorter
+// rows and columns are not wrapped, as their ends would be connected both ways in two directions
+func (g *generator) wrap() {
+	for y := 0; y < g.height; y++ {
+		first, exists := g.cities[structs.GridPosition{X: 0, Y: y}]
+		if !exists {
+			continue
+		}
+		last := first
+		for next, exists := g.neighbour(last, structs.East); exists; next, exists = g.neighbour(last, structs.East) {
+			last = next
+		}
+		if g.positions[last].X >= 2 {
+			g.connect(last, structs.East, first)
+		}
+	}
+	for x := 0; x < g.width; x++ {
+		first, exists := g.cities[structs.GridPosition{X: x, Y: 0}]
+		if !exists {
+			continue
+		}
+		last := first
+		for next, exists := g.neighbour(last, structs.South); exists; next, exists = g.neighbour(last, structs.South) {
+			last = next
+		}
+		if g.positions[last].Y >= 2 {
+			g.connect(last, structs.South, first)
+		}
+	}
+}
+
+// connectRandomly gives every city a random number of 1 to 4 roads, adding the roads of the grid
+// in a random order as long as both of their ends need more roads. Cities left without a road are
+// then connected to their first neighbour on the grid
+func (g *generator) connectRandomly() {
+	wanted := make([]int, len(g.positions))
+	type gridRoad struct {
+		city int
+		dir  structs.Direction
+	}
+	gridRoads := []gridRoad{}
+	for city := range g.positions {
+		wanted[city] = 1 + g.rng.Intn(4)
+		for _, dir := range []structs.Direction{structs.East, structs.South} {
+			if _, exists := g.neighbour(city, dir); exists {
+				gridRoads = append(gridRoads, gridRoad{city: city, dir: dir})
+			}
+		}
+	}
+	g.rng.Shuffle(len(gridRoads), func(i, j int) { gridRoads[i], gridRoads[j] = gridRoads[j], gridRoads[i] })
+
+	for _, road := range gridRoads {
+		neighbour, _ := g.neighbour(road.city, road.dir)
+		if g.degree(road.city) < wanted[road.city] && g.degree(neighbour) < wanted[neighbour] {
+			g.connect(road.city, road.dir, neighbour)
+		}
+	}
+	for city := range g.positions {
+		if g.degree(city) > 0 {
+			continue
+		}
+		for _, dir := range []structs.Direction{structs.North, structs.East, structs.South, structs.West} {
+			if neighbour, exists := g.neighbour(city, dir); exists {
+				g.connect(city, dir, neighbour)
+				break
+			}
+		}
+	}
+}
+
+// coordinateNames names every city after its column and row, both written in letters like the
+// columns of a spreadsheet (e.g. B-AA for the second column of the 27th row)
+func (g *generator) coordinateNames() []string {
+	names := make([]string, len(g.positions))
+	for city, position := range g.positions {
+		names[city] = lettersOf(position.X) + "-" + lettersOf(position.Y)
+	}
+	return names
+}
+
+// lettersOf writes the number in letters: A to Z, then AA to ZZ and so on
+func lettersOf(n int) string {
+	letters := []byte{}
+	for n++; n > 0; n = (n - 1) / 26 {
+		letters = append([]byte{byte('A' + (n-1)%26)}, letters...)
+	}
+	return string(letters)
+}
+
+// petNames gives every city a unique capitalized pet name (e.g. Brave-Otter). Names get longer
+// once short names keep clashing with the names already given
+func (g *generator) petNames() []string {
+	names := make([]string, len(g.positions))
+	taken := make(map[string]bool, len(g.positions))
+	wordCount := 2
+	for city := range names {
+		for attempt := 1; ; attempt++ {
+			words := strings.Split(utils.GeneratePetName(g.rng, wordCount, "-"), "-")
+			for i, word := range words {
+				words[i] = strings.ToUpper(word[:1]) + word[1:]
+			}
+			name := strings.Join(words, "-")
+			if !taken[name] {
+				taken[name] = true
+				names[city] = name
+				break
+			}
+			if attempt%10 == 0 {
+				wordCount++
+			}
+		}
+	}
+	return names
+}
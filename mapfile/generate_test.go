@@ -0,0 +1,119 @@
+package mapfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// roadCounts returns the number of cities and roads of the map, counting every two-way road once
+func roadCounts(m *Map) (int, int) {
+	roads := 0
+	for _, city := range m.Cities {
+		roads += len(city.Roads)
+	}
+	return len(m.Cities), roads / 2
+}
+
+// line returns the city as a line of a text map
+func line(city CityEntry) string {
+	var text strings.Builder
+	TextLoader{}.Write(&text, &Map{Cities: []CityEntry{city}})
+	return strings.TrimSuffix(text.String(), "\n")
+}
+
+// reachable returns the number of cities which can be reached from the first city of the map
+func reachable(m *Map) int {
+	mapInfo := m.MapInfo()
+	visited := map[string]bool{m.Cities[0].Name: true}
+	queue := []string{m.Cities[0].Name}
+	for len(queue) > 0 {
+		for _, road := range mapInfo[queue[0]] {
+			neighbour := strings.SplitN(road, "=", 2)[1]
+			if !visited[neighbour] {
+				visited[neighbour] = true
+				queue = append(queue, neighbour)
+			}
+		}
+		queue = queue[1:]
+	}
+	return len(visited)
+}
+
+func TestGenerateMap(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, topology := range []Topology{GridTopology, DropoutTopology, TreeTopology, TorusTopology, ChainTopology, RandomTopology} {
+		for _, naming := range []NamingScheme{CoordinateNames, PetNames} {
+			config := GeneratorConfig{Topology: topology, Width: 7, Height: 5, Cities: 32, Dropout: 0.3, Naming: naming, Seed: 42}
+			m, err := GenerateMap(config)
+			assert.Nil(err, "Generating a %s map should not fail.", topology)
+			assert.Len(m.Cities, 32, "The %s map should have the requested number of cities.", topology)
+
+			var text strings.Builder
+			TextLoader{}.Write(&text, m)
+			report, _ := ValidateMap("generated.txt", strings.NewReader(text.String()))
+			assert.Empty(report.Problems, "The %s map with %s names should be valid.", topology, naming)
+
+			again, _ := GenerateMap(config)
+			assert.Equal(m, again, "The same seed should generate the same %s map.", topology)
+		}
+	}
+}
+
+func TestGenerateMapTopologies(t *testing.T) {
+	assert := assert.New(t)
+
+	m, _ := GenerateMap(GeneratorConfig{Topology: GridTopology, Width: 3, Height: 2})
+	assert.Equal("A-A east=B-A south=A-B", line(m.Cities[0]))
+	assert.Equal("B-B north=B-A east=C-B west=A-B", line(m.Cities[4]))
+	_, roads := roadCounts(m)
+	assert.Equal(7, roads, "A full grid should connect every city to its neighbours.")
+
+	m, _ = GenerateMap(GeneratorConfig{Topology: TorusTopology, Width: 4, Height: 3})
+	for _, city := range m.Cities {
+		assert.Len(city.Roads, 4, "Every city of a torus should have a road in every direction.")
+	}
+	// the two cities of a row are not wrapped around
+	m, _ = GenerateMap(GeneratorConfig{Topology: TorusTopology, Width: 2, Height: 3})
+	assert.Equal("A-A north=A-C east=B-A south=A-B", line(m.Cities[0]))
+
+	m, _ = GenerateMap(GeneratorConfig{Topology: TreeTopology, Cities: 50, Seed: 7})
+	cities, roads := roadCounts(m)
+	assert.Equal(cities-1, roads, "A spanning tree should have one road less than its cities.")
+	assert.Equal(cities, reachable(m), "A spanning tree should connect every city.")
+
+	m, _ = GenerateMap(GeneratorConfig{Topology: ChainTopology, Cities: 4})
+	assert.Equal("D-A west=C-A", line(m.Cities[3]), "A chain should default to a single row.")
+	m, _ = GenerateMap(GeneratorConfig{Topology: ChainTopology, Width: 2, Height: 2})
+	assert.Equal([]string{"A-A east=B-A", "B-A south=B-B west=A-A", "B-B north=B-A west=A-B", "A-B east=B-B"},
+		[]string{line(m.Cities[0]), line(m.Cities[1]), line(m.Cities[2]), line(m.Cities[3])}, "A chain should snake through the grid.")
+
+	m, _ = GenerateMap(GeneratorConfig{Topology: RandomTopology, Cities: 100, Seed: 7})
+	for _, city := range m.Cities {
+		assert.True(len(city.Roads) >= 1 && len(city.Roads) <= 4, "Every city should have between 1 and 4 roads.")
+	}
+
+	m, _ = GenerateMap(GeneratorConfig{Topology: GridTopology, Width: 27, Height: 1})
+	assert.Equal("AA-A", m.Cities[26].Name, "Coordinates should be written in letters.")
+}
+
+func TestGenerateMapRejectsInvalidConfigs(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, config := range []GeneratorConfig{
+		{},
+		{Width: 3},
+		{Width: 3, Height: 3, Cities: 10},
+		{Cities: -1},
+		{Topology: DropoutTopology, Cities: 4, Dropout: 1.5},
+	} {
+		_, err := GenerateMap(config)
+		assert.IsType(&InvalidGeneratorConfigError{}, err, "The config %+v should be rejected.", config)
+	}
+	_, err := ParseTopology("hexagonal")
+	assert.IsType(&InvalidTopologyError{}, err)
+	_, err = ParseNamingScheme("numbers")
+	assert.IsType(&InvalidNamingSchemeError{}, err)
+}
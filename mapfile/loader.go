@@ -0,0 +1,305 @@
+package mapfile
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type MapFormat int64
+
+// enum to represent the formats a map file can be written in
+const (
+	TextFormat MapFormat = iota
+	JSONFormat
+	YAMLFormat
+)
+
+// String returns a representation of the given map format
+func (format MapFormat) String() string {
+	switch format {
+	case TextFormat:
+		return "text"
+	case JSONFormat:
+		return "json"
+	case YAMLFormat:
+		return "yaml"
+	}
+	return "invalid-map-format"
+}
+
+// ParseMapFormat returns the map format with the given name
+func ParseMapFormat(name string) (MapFormat, error) {
+	for _, format := range []MapFormat{TextFormat, JSONFormat, YAMLFormat} {
+		if format.String() == name {
+			return format, nil
+		}
+	}
+	if name == "yml" {
+		return YAMLFormat, nil
+	}
+	return TextFormat, &InvalidMapFormatError{name: name}
+}
+
+// MapFormatOf returns the map format matching the extension of the file name, the text format
+// for unknown extensions
+func MapFormatOf(fileName string) MapFormat {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		return JSONFormat
+	case ".yaml", ".yml":
+		return YAMLFormat
+	}
+	return TextFormat
+}
+
+// ResolveMapFormat returns the map format with the given name, or the format matching the
+// extension of the file name if no name is given
+func ResolveMapFormat(fileName string, name string) (MapFormat, error) {
+	if name == "" {
+		return MapFormatOf(fileName), nil
+	}
+	return ParseMapFormat(name)
+}
+
+// Map holds the cities of a map in the order they are declared, along with their roads and
+// optional attributes. Attributes are kept by the JSON and YAML formats and ignored by the world
+type Map struct {
+	Cities []CityEntry `json:"cities" yaml:"cities"`
+}
+
+// CityEntry holds a city of a map along with its roads
+type CityEntry struct {
+	Name       string                 `json:"name" yaml:"name"`
+	Roads      []RoadEntry            `json:"roads,omitempty" yaml:"roads,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+// RoadEntry holds a road leading from a city in the given direction to a neighbour
+type RoadEntry struct {
+	Direction  string                 `json:"direction" yaml:"direction"`
+	To         string                 `json:"to" yaml:"to"`
+	Attributes map[string]interface{} `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+// MapInfo returns the roads of every city in the dir=Neighbour form read by World.InitializeWorld
+func (m *Map) MapInfo() map[string][]string {
+	mapInfo := make(map[string][]string)
+	for _, city := range m.Cities {
+		roads := []string{}
+		for _, road := range city.Roads {
+			roads = append(roads, road.Direction+"="+road.To)
+		}
+		mapInfo[city.Name] = roads
+	}
+	return mapInfo
+}
+
+// HasAttributes checks whether any city or road of the map has attributes
+func (m *Map) HasAttributes() bool {
+	for _, city := range m.Cities {
+		if len(city.Attributes) != 0 {
+			return true
+		}
+		for _, road := range city.Roads {
+			if len(road.Attributes) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// check makes sure the cities follow the rules of the text format: every city is declared once with
+// a name of english letters and dashes, and has at most one road in each known direction. Roads to
+// undeclared cities and roads without a road back are left to the validation, as in text maps
+func (m *Map) check() error {
+	for _, problem := range validateCities("", m).Problems {
+		if problem.Kind != UndeclaredCity && problem.Kind != NonReciprocalLink {
+			return &InvalidMapError{cityIndex: problem.City, reason: problem.Message}
+		}
+	}
+	return nil
+}
+
+// MapLoader reads and writes maps in a single format
+type MapLoader interface {
+	// Load reads a map from the reader
+	Load(in io.Reader) (*Map, error)
+
+	// Write writes the map to the writer
+	Write(out io.Writer, m *Map) error
+
+	// String returns the name of the format
+	String() string
+}
+
+// LoaderFor returns the loader of the given map format
+func LoaderFor(format MapFormat) MapLoader {
+	switch format {
+	case JSONFormat:
+		return JSONLoader{}
+	case YAMLFormat:
+		return YAMLLoader{}
+	}
+	return TextLoader{}
+}
+
+// LoadMapFile reads the map file with the given name in the named format, or in the format
+// matching its extension if no format is named
+func LoadMapFile(fileName string, formatName string) (*Map, error) {
+	format, err := ResolveMapFormat(fileName, formatName)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoaderFor(format).Load(file)
+}
+
+// LoadMapInfo reads the map file with the given name, see LoadMapFile, and returns the roads of
+// every city in the form read by World.InitializeWorld
+func LoadMapInfo(fileName string, formatName string) (map[string][]string, error) {
+	m, err := LoadMapFile(fileName, formatName)
+	if err != nil {
+		return nil, err
+	}
+	return m.MapInfo(), nil
+}
+
+// ConvertMap reads a map in one format and writes it in another, returning the converted map
+func ConvertMap(in io.Reader, from MapFormat, out io.Writer, to MapFormat) (*Map, error) {
+	m, err := LoaderFor(from).Load(in)
+	if err != nil {
+		return nil, err
+	}
+	return m, LoaderFor(to).Write(out, m)
+}
+
+// TextLoader reads and writes maps with one "Name dir=Neighbour ..." line per city. Attributes
+// cannot be written in this format and are dropped
+type TextLoader struct{}
+
+func (loader TextLoader) Load(in io.Reader) (*Map, error) {
+	m := &Map{Cities: []CityEntry{}}
+
+	fileScanner := bufio.NewScanner(in)
+	fileScanner.Split(bufio.ScanLines)
+	for fileScanner.Scan() {
+		if strings.TrimSpace(fileScanner.Text()) == "" {
+			continue
+		}
+		// tokens are split on single spaces, empty tokens left by repeated spaces are skipped
+		cityInfo := strings.Split(fileScanner.Text(), " ")
+		city := CityEntry{Name: cityInfo[0]}
+		for _, neighbourInfo := range cityInfo[1:] {
+			if neighbourInfo == "" {
+				continue
+			}
+			temp := strings.Split(neighbourInfo, "=")
+			if len(temp) != 2 {
+				return nil, &InvalidMapError{city: city.Name, reason: "road " + neighbourInfo + " is not in the direction=City form"}
+			}
+			city.Roads = append(city.Roads, RoadEntry{Direction: temp[0], To: temp[1]})
+		}
+		m.Cities = append(m.Cities, city)
+	}
+	if err := fileScanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (loader TextLoader) Write(out io.Writer, m *Map) error {
+	writer := bufio.NewWriter(out)
+	for _, city := range m.Cities {
+		var line strings.Builder
+		line.WriteString(city.Name)
+		for _, road := range city.Roads {
+			line.WriteString(" " + road.Direction + "=" + road.To)
+		}
+		if _, err := writer.WriteString(line.String() + "\n"); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func (loader TextLoader) String() string {
+	return TextFormat.String()
+}
+
+// JSONLoader reads and writes maps as a JSON document, rejecting unknown fields
+type JSONLoader struct{}
+
+func (loader JSONLoader) Load(in io.Reader) (*Map, error) {
+	m, err := decodeJSONMap(in)
+	if err != nil {
+		return nil, err
+	}
+	return m, m.check()
+}
+
+// decodeJSONMap reads a JSON map without checking its cities
+func decodeJSONMap(in io.Reader) (*Map, error) {
+	decoder := json.NewDecoder(in)
+	decoder.DisallowUnknownFields()
+	m := &Map{}
+	if err := decoder.Decode(m); err != nil {
+		return nil, &InvalidMapError{reason: err.Error()}
+	}
+	return m, nil
+}
+
+func (loader JSONLoader) Write(out io.Writer, m *Map) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m)
+}
+
+func (loader JSONLoader) String() string {
+	return JSONFormat.String()
+}
+
+// YAMLLoader reads and writes maps as a YAML document, rejecting unknown fields
+type YAMLLoader struct{}
+
+func (loader YAMLLoader) Load(in io.Reader) (*Map, error) {
+	m, err := decodeYAMLMap(in)
+	if err != nil {
+		return nil, err
+	}
+	return m, m.check()
+}
+
+// decodeYAMLMap reads a YAML map without checking its cities
+func decodeYAMLMap(in io.Reader) (*Map, error) {
+	decoder := yaml.NewDecoder(in)
+	decoder.KnownFields(true)
+	m := &Map{}
+	if err := decoder.Decode(m); err != nil && err != io.EOF {
+		return nil, &InvalidMapError{reason: err.Error()}
+	}
+	return m, nil
+}
+
+func (loader YAMLLoader) Write(out io.Writer, m *Map) error {
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(m); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func (loader YAMLLoader) String() string {
+	return YAMLFormat.String()
+}
@@ -0,0 +1,107 @@
+package mapfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapFormats(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, format := range []MapFormat{TextFormat, JSONFormat, YAMLFormat} {
+		parsed, err := ParseMapFormat(format.String())
+		assert.Nil(err, "Map format %s should be parsed.", format)
+		assert.Equal(format, parsed)
+	}
+	_, err := ParseMapFormat("xml")
+	assert.IsType(&InvalidMapFormatError{}, err, "Unknown map formats should be rejected.")
+
+	assert.Equal(JSONFormat, MapFormatOf("maps/map.JSON"))
+	assert.Equal(YAMLFormat, MapFormatOf("map.yml"))
+	assert.Equal(TextFormat, MapFormatOf("map.txt"))
+	assert.Equal(TextFormat, MapFormatOf("map"), "Maps without an extension should be read as text.")
+
+	format, _ := ResolveMapFormat("map.txt", "yaml")
+	assert.Equal(YAMLFormat, format, "A named format should take precedence over the extension.")
+}
+
+func TestConvertMap(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := "Foo north=Bar west=Baz\nBar south=Foo\n\nBaz east=Foo\n"
+	expected := &Map{Cities: []CityEntry{
+		{Name: "Foo", Roads: []RoadEntry{{Direction: "north", To: "Bar"}, {Direction: "west", To: "Baz"}}},
+		{Name: "Bar", Roads: []RoadEntry{{Direction: "south", To: "Foo"}}},
+		{Name: "Baz", Roads: []RoadEntry{{Direction: "east", To: "Foo"}}},
+	}}
+
+	// the map survives a round trip through every format
+	text := contents
+	for _, formats := range [][2]MapFormat{{TextFormat, JSONFormat}, {JSONFormat, YAMLFormat}, {YAMLFormat, TextFormat}} {
+		var out strings.Builder
+		m, err := ConvertMap(strings.NewReader(text), formats[0], &out, formats[1])
+		assert.Nil(err, "Converting from %s to %s should not fail.", formats[0], formats[1])
+		assert.Equal(expected, m, "The %s map should hold every city and road in order.", formats[0])
+		text = out.String()
+	}
+	assert.Equal(strings.Replace(contents, "\n\n", "\n", 1), text, "The round trip should give back the text map.")
+
+	// every format loads into the same world
+	world := structs.CreateWorld()
+	world.InitializeWorld(expected.MapInfo())
+	var remaining strings.Builder
+	world.WriteMap(&remaining)
+	assert.Equal("Bar south=Foo\nBaz east=Foo\nFoo north=Bar west=Baz\n", remaining.String())
+}
+
+func TestLoadMapAttributes(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := "cities:\n" +
+		"  - name: Foo\n" +
+		"    attributes: {population: 120}\n" +
+		"    roads:\n" +
+		"      - {direction: north, to: Bar, attributes: {toll: true}}\n" +
+		"  - name: Bar\n" +
+		"    roads: [{direction: south, to: Foo}]\n"
+	m, err := YAMLLoader{}.Load(strings.NewReader(contents))
+	assert.Nil(err, "A YAML map with attributes should be loaded.")
+	assert.True(m.HasAttributes())
+	assert.Equal(map[string]interface{}{"population": 120}, m.Cities[0].Attributes)
+	assert.Equal(map[string][]string{"Foo": {"north=Bar"}, "Bar": {"south=Foo"}}, m.MapInfo(), "Attributes should not reach the world.")
+
+	var out strings.Builder
+	JSONLoader{}.Write(&out, m)
+	reloaded, err := JSONLoader{}.Load(strings.NewReader(out.String()))
+	assert.Nil(err)
+	assert.Equal(float64(120), reloaded.Cities[0].Attributes["population"], "Attributes should be kept in JSON.")
+	assert.Equal(true, reloaded.Cities[0].Roads[0].Attributes["toll"])
+}
+
+func TestLoadInvalidMaps(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, test := range []struct {
+		loader   MapLoader
+		contents string
+	}{
+		{TextLoader{}, "Foo north\n"},
+		{TextLoader{}, "Foo north=Bar=Baz\n"},
+		{JSONLoader{}, `{"cities": [{"name": "Foo", "population": 3}]}`},
+		{JSONLoader{}, `{"cities": [{"roads": []}]}`},
+		{JSONLoader{}, `{"cities": [{"name": "Foo", "roads": [{"direction": "north"}]}]}`},
+		{JSONLoader{}, `{"cities": [{"name": "Foo"}, {"name": "Foo"}]}`},
+		{JSONLoader{}, `{"cities": [{"name": "X Y=Z"}]}`},
+		{JSONLoader{}, `{"cities": [{"name": "Foo", "roads": [{"direction": "up", "to": "Bar"}]}, {"name": "Bar"}]}`},
+		{JSONLoader{}, `{"cities": [{"name": "Foo", "roads": [{"direction": "north", "to": "B=C"}]}]}`},
+		{YAMLLoader{}, "cities:\n  - name: Foo\n    road: []\n"},
+		{YAMLLoader{}, "cities: Foo\n"},
+		{YAMLLoader{}, "cities:\n  - name: Foo\n    roads:\n      - {direction: north, to: Bar}\n      - {direction: north, to: Baz}\n"},
+	} {
+		_, err := test.loader.Load(strings.NewReader(test.contents))
+		assert.IsType(&InvalidMapError{}, err, "The %s map %q should be rejected.", test.loader, test.contents)
+	}
+}
@@ -0,0 +1,68 @@
+package mapfile
+
+import "fmt"
+
+type ProblemKind int64
+
+// enum to represent the kinds of problems a map file can have
+const (
+	MalformedLink ProblemKind = iota
+	UnknownDirection
+	DuplicateCity
+	DuplicateDirection
+	UndeclaredCity
+	NonReciprocalLink
+	IllegalCityName
+)
+
+// String returns a representation of the given problem kind
+func (kind ProblemKind) String() string {
+	switch kind {
+	case MalformedLink:
+		return "malformed-link"
+	case UnknownDirection:
+		return "unknown-direction"
+	case DuplicateCity:
+		return "duplicate-city"
+	case DuplicateDirection:
+		return "duplicate-direction"
+	case UndeclaredCity:
+		return "undeclared-city"
+	case NonReciprocalLink:
+		return "non-reciprocal-link"
+	case IllegalCityName:
+		return "illegal-city-name"
+	}
+	return "invalid-problem-kind"
+}
+
+// MarshalText represents the problem kind by its name in JSON reports
+func (kind ProblemKind) MarshalText() ([]byte, error) {
+	return []byte(kind.String()), nil
+}
+
+// Problem holds information about a single problem found in a map file, found on a line of a text map
+// or in a city of a JSON or YAML map, counted from 1
+type Problem struct {
+	File    string      `json:"file"`
+	Line    int         `json:"line,omitempty"`
+	City    int         `json:"city,omitempty"`
+	Kind    ProblemKind `json:"kind"`
+	Message string      `json:"message"`
+}
+
+// String returns a representation of the problem in the file:line: message form,
+// or in the file: city #index: message form for a problem found in a city
+func (p Problem) String() string {
+	if p.City > 0 {
+		return fmt.Sprintf("%s: city #%d: %s: %s", p.File, p.City, p.Kind, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", p.File, p.Line, p.Kind, p.Message)
+}
+
+// Report holds all problems found in a map file
+type Report struct {
+	File     string    `json:"file"`
+	Passed   bool      `json:"passed"`
+	Problems []Problem `json:"problems"`
+}
@@ -0,0 +1,112 @@
+package mapfile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+)
+
+type RepairKind int64
+
+// enum to represent the kinds of changes made while repairing a map
+const (
+	AddedCity RepairKind = iota
+	AddedRoad
+)
+
+// String returns a representation of the given repair kind
+func (kind RepairKind) String() string {
+	switch kind {
+	case AddedCity:
+		return "added-city"
+	case AddedRoad:
+		return "added-road"
+	}
+	return "invalid-repair-kind"
+}
+
+// RepairChange holds information about a change inferred while repairing a map
+type RepairChange struct {
+	Kind RepairKind
+
+	// city which was added, or which the road was added to
+	City string
+
+	// direction and neighbour of the added road
+	Direction structs.Direction
+	Neighbour string
+
+	// road which the change was inferred from
+	Cause string
+}
+
+// String returns a representation of the change
+func (c RepairChange) String() string {
+	if c.Kind == AddedCity {
+		return fmt.Sprintf("%s: %s (referenced by %s)", c.Kind, c.City, c.Cause)
+	}
+	return fmt.Sprintf("%s: %s %s=%s (reverse of %s)", c.Kind, c.City, c.Direction.MapKeyword(), c.Neighbour, c.Cause)
+}
+
+// RepairMap infers the roads and cities missing from the map information: the reverse of every
+// one-way road, and a line for every city which only appears as a neighbour. A city with two roads
+// in the same direction cannot be repaired.
+// It returns the repaired map information along with every inferred change, in a stable order
+func RepairMap(mapInfo map[string][]string) (map[string][]string, []RepairChange, error) {
+	cityNames := make([]string, 0, len(mapInfo))
+	cityLinks := make(map[string]map[structs.Direction]string)
+	for cityName, neighbourNames := range mapInfo {
+		cityNames = append(cityNames, cityName)
+		cityLinks[cityName] = make(map[structs.Direction]string)
+		for _, neighbourInfo := range neighbourNames {
+			temp := strings.Split(neighbourInfo, "=")
+			if len(temp) != 2 || temp[1] == "" || structs.StringToDirection(temp[0]) == structs.Invalid {
+				return nil, nil, &MalformedRoadError{city: cityName, road: neighbourInfo}
+			}
+			direction := structs.StringToDirection(temp[0])
+			if existing, exists := cityLinks[cityName][direction]; exists && existing != temp[1] {
+				return nil, nil, &RepairConflictError{city: cityName, direction: direction, existing: existing, inferred: temp[1], declared: true}
+			}
+			cityLinks[cityName][direction] = temp[1]
+		}
+	}
+	sort.Strings(cityNames)
+
+	changes := []RepairChange{}
+	for _, cityName := range cityNames {
+		for _, dir := range []structs.Direction{structs.North, structs.East, structs.South, structs.West} {
+			neighbourName, exists := cityLinks[cityName][dir]
+			if !exists {
+				continue
+			}
+			cause := cityName + " " + dir.MapKeyword() + "=" + neighbourName
+
+			if _, declared := cityLinks[neighbourName]; !declared {
+				cityLinks[neighbourName] = make(map[structs.Direction]string)
+				changes = append(changes, RepairChange{Kind: AddedCity, City: neighbourName, Cause: cause})
+			}
+
+			opposite := dir.Opposite()
+			existing, hasRoad := cityLinks[neighbourName][opposite]
+			if !hasRoad {
+				cityLinks[neighbourName][opposite] = cityName
+				changes = append(changes, RepairChange{Kind: AddedRoad, City: neighbourName, Direction: opposite, Neighbour: cityName, Cause: cause})
+			} else if existing != cityName {
+				return nil, nil, &RepairConflictError{city: neighbourName, direction: opposite, existing: existing, inferred: cityName}
+			}
+		}
+	}
+
+	repaired := make(map[string][]string)
+	for cityName, links := range cityLinks {
+		repaired[cityName] = []string{}
+		for _, dir := range []structs.Direction{structs.North, structs.East, structs.South, structs.West} {
+			if neighbourName, exists := links[dir]; exists {
+				repaired[cityName] = append(repaired[cityName], dir.MapKeyword()+"="+neighbourName)
+			}
+		}
+	}
+	return repaired, changes, nil
+}
@@ -0,0 +1,69 @@
+package mapfile
+
+import (
+	"testing"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepairMap(t *testing.T) {
+	assert := assert.New(t)
+
+	mapInfo := map[string][]string{
+		"Foo": {"north=Bar", "west=Baz", "south=Qu-ux"},
+		"Bar": {"south=Foo", "west=Bee"},
+	}
+	repaired, changes, err := RepairMap(mapInfo)
+
+	assert.Nil(err, "The map should be repairable.")
+	assert.Equal(map[string][]string{
+		"Foo":   {"north=Bar", "south=Qu-ux", "west=Baz"},
+		"Bar":   {"south=Foo", "west=Bee"},
+		"Baz":   {"east=Foo"},
+		"Qu-ux": {"north=Foo"},
+		"Bee":   {"east=Bar"},
+	}, repaired, "Missing cities and reverse roads should be inferred.")
+	assert.Equal([]RepairChange{
+		{Kind: AddedCity, City: "Bee", Cause: "Bar west=Bee"},
+		{Kind: AddedRoad, City: "Bee", Direction: structs.East, Neighbour: "Bar", Cause: "Bar west=Bee"},
+		{Kind: AddedCity, City: "Qu-ux", Cause: "Foo south=Qu-ux"},
+		{Kind: AddedRoad, City: "Qu-ux", Direction: structs.North, Neighbour: "Foo", Cause: "Foo south=Qu-ux"},
+		{Kind: AddedCity, City: "Baz", Cause: "Foo west=Baz"},
+		{Kind: AddedRoad, City: "Baz", Direction: structs.East, Neighbour: "Foo", Cause: "Foo west=Baz"},
+	}, changes, "Every inferred change should be reported.")
+	assert.Equal("added-road: Bee east=Bar (reverse of Bar west=Bee)", changes[1].String())
+}
+
+func TestRepairMapConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	mapInfo := map[string][]string{
+		"Foo": {"north=Bar"},
+		"Bar": {"south=Baz"},
+		"Baz": {"north=Bar"},
+	}
+	_, _, err := RepairMap(mapInfo)
+
+	assert.NotNil(err, "Clashing roads should not be repairable.")
+	assert.IsType(&RepairConflictError{}, err, "A conflict error should be raised.")
+	assert.Equal("Cannot infer road south=Foo for Bar: it already has road south=Baz.\n", err.Error())
+}
+
+func TestRepairMapRepeatedDirection(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := RepairMap(map[string][]string{"Foo": {"north=Bar", "north=Baz"}})
+	assert.IsType(&RepairConflictError{}, err, "Two roads in the same direction should not be repairable.")
+	assert.Equal("Cannot repair Foo: it has both road north=Bar and road north=Baz.\n", err.Error())
+
+	_, _, err = RepairMap(map[string][]string{"Foo": {"north=Bar", "north=Bar"}})
+	assert.Nil(err, "A road declared twice should be repairable.")
+}
+
+func TestRepairMapMalformedRoad(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := RepairMap(map[string][]string{"Foo": {"up=Bar"}})
+	assert.IsType(&MalformedRoadError{}, err, "Malformed roads should not be repairable.")
+}
@@ -0,0 +1,240 @@
+package mapfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+)
+
+// declaredLink holds a road to a neighbour as declared by a city of the map file
+type declaredLink struct {
+	direction structs.Direction
+	neighbour string
+	position  int
+}
+
+// repeatedLink holds a road declared by a city which had already been declared
+type repeatedLink struct {
+	city string
+	link declaredLink
+}
+
+// mapEntry holds a city as declared in a map file along with its roads, and its position in the file:
+// the line of a text map, or the index of the city from 1 in a JSON or YAML map
+type mapEntry struct {
+	position int
+	name     string
+	roads    []entryRoad
+}
+
+// entryRoad holds a road as declared by a city, and whether it lacks a direction or a neighbour
+type entryRoad struct {
+	text      string
+	direction string
+	neighbour string
+	malformed bool
+}
+
+// ValidateMapFile checks the map file with the given name, in the named format or in the format
+// matching its extension, and reports every problem found in it. Problems of JSON and YAML maps are
+// reported at the index of their city in the map, as they have no lines of their own
+func ValidateMapFile(fileName string, formatName string) (*Report, error) {
+	format, err := ResolveMapFormat(fileName, formatName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch format {
+	case JSONFormat:
+		m, err := decodeJSONMap(file)
+		if err != nil {
+			return nil, err
+		}
+		return validateCities(fileName, m), nil
+	case YAMLFormat:
+		m, err := decodeYAMLMap(file)
+		if err != nil {
+			return nil, err
+		}
+		return validateCities(fileName, m), nil
+	}
+	return ValidateMap(fileName, file)
+}
+
+// ValidateMap checks the text map read from the given reader and reports every problem found in it.
+// The file name is only used to label the problems
+func ValidateMap(fileName string, in io.Reader) (*Report, error) {
+	entries := []mapEntry{}
+
+	fileScanner := bufio.NewScanner(in)
+	fileScanner.Split(bufio.ScanLines)
+	lineNumber := 0
+	for fileScanner.Scan() {
+		lineNumber++
+		// tokens are split the same way the map file parser splits them
+		cityInfo := strings.Split(fileScanner.Text(), " ")
+		entry := mapEntry{position: lineNumber, name: cityInfo[0]}
+		for _, neighbourInfo := range cityInfo[1:] {
+			temp := strings.Split(neighbourInfo, "=")
+			road := entryRoad{text: neighbourInfo, malformed: len(temp) != 2 || temp[0] == "" || temp[1] == ""}
+			if !road.malformed {
+				road.direction, road.neighbour = temp[0], temp[1]
+			}
+			entry.roads = append(entry.roads, road)
+		}
+		entries = append(entries, entry)
+	}
+	if err := fileScanner.Err(); err != nil {
+		return nil, err
+	}
+	return validateEntries(fileName, entries, false), nil
+}
+
+// validateCities checks the cities of a JSON or YAML map and reports every problem found in them
+func validateCities(fileName string, m *Map) *Report {
+	entries := make([]mapEntry, 0, len(m.Cities))
+	for i, city := range m.Cities {
+		entry := mapEntry{position: i + 1, name: city.Name}
+		for _, road := range city.Roads {
+			entry.roads = append(entry.roads, entryRoad{
+				text:      road.Direction + "=" + road.To,
+				direction: road.Direction,
+				neighbour: road.To,
+				malformed: road.Direction == "" || road.To == "",
+			})
+		}
+		entries = append(entries, entry)
+	}
+	return validateEntries(fileName, entries, true)
+}
+
+// validateEntries checks the declared cities and reports every problem found in them, at the
+// index of their city if byCity is set and at their line otherwise
+func validateEntries(fileName string, entries []mapEntry, byCity bool) *Report {
+	report := &Report{File: fileName, Problems: []Problem{}}
+	addProblem := func(position int, kind ProblemKind, format string, args ...interface{}) {
+		problem := Problem{File: fileName, Line: position, Kind: kind, Message: fmt.Sprintf(format, args...)}
+		if byCity {
+			problem.Line, problem.City = 0, position
+		}
+		report.Problems = append(report.Problems, problem)
+	}
+	where := func(position int) string {
+		if byCity {
+			return fmt.Sprintf("as city #%d", position)
+		}
+		return fmt.Sprintf("on line %d", position)
+	}
+
+	// position at which each city was first declared
+	declaredCities := make(map[string]int)
+	// valid links of each city, keyed by direction
+	cityLinks := make(map[string]map[structs.Direction]declaredLink)
+	// names of the cities in the order they were declared
+	cityOrder := []string{}
+	// valid links of the cities declared again, only checked for undeclared neighbours
+	repeatedLinks := []repeatedLink{}
+
+	for _, entry := range entries {
+		cityName := entry.name
+
+		if !isValidCityName(cityName) {
+			addProblem(entry.position, IllegalCityName, "city name %q may only contain english letters and dashes", cityName)
+		}
+		firstPosition, repeated := declaredCities[cityName]
+		if repeated {
+			addProblem(entry.position, DuplicateCity, "city %q is already declared %s", cityName, where(firstPosition))
+		}
+
+		links := make(map[structs.Direction]declaredLink)
+		for _, road := range entry.roads {
+			if road.malformed {
+				addProblem(entry.position, MalformedLink, "road %q of %q is not in the direction=City form", road.text, cityName)
+				continue
+			}
+
+			direction := structs.StringToDirection(road.direction)
+			if direction == structs.Invalid {
+				addProblem(entry.position, UnknownDirection, "road %q of %q has an unknown direction %q", road.text, cityName, road.direction)
+				continue
+			}
+			if !isValidCityName(road.neighbour) {
+				addProblem(entry.position, IllegalCityName, "city name %q may only contain english letters and dashes", road.neighbour)
+				continue
+			}
+			if existingLink, exists := links[direction]; exists {
+				addProblem(entry.position, DuplicateDirection, "%q has more than one road to the %s (%s and %s)", cityName, road.direction, existingLink.neighbour, road.neighbour)
+				continue
+			}
+			links[direction] = declaredLink{direction: direction, neighbour: road.neighbour, position: entry.position}
+		}
+
+		// the roads of a city declared again are checked, but only its first declaration counts
+		if repeated {
+			for _, direction := range []structs.Direction{structs.North, structs.East, structs.South, structs.West} {
+				if link, exists := links[direction]; exists {
+					repeatedLinks = append(repeatedLinks, repeatedLink{city: cityName, link: link})
+				}
+			}
+			continue
+		}
+		declaredCities[cityName] = entry.position
+		cityLinks[cityName] = links
+		cityOrder = append(cityOrder, cityName)
+	}
+
+	// every road must lead to a declared city which has a road back in the opposite direction
+	for _, cityName := range cityOrder {
+		for _, direction := range []structs.Direction{structs.North, structs.East, structs.South, structs.West} {
+			link, exists := cityLinks[cityName][direction]
+			if !exists {
+				continue
+			}
+			if _, declared := declaredCities[link.neighbour]; !declared {
+				addProblem(link.position, UndeclaredCity, "%q has a road to %q which is not declared", cityName, link.neighbour)
+				continue
+			}
+			opposite := direction.Opposite()
+			if backLink, exists := cityLinks[link.neighbour][opposite]; !exists || backLink.neighbour != cityName {
+				addProblem(link.position, NonReciprocalLink, "%q has road %s=%s, but %q has no road %s=%s", cityName, direction.MapKeyword(), link.neighbour, link.neighbour, opposite.MapKeyword(), cityName)
+			}
+		}
+	}
+
+	for _, repeated := range repeatedLinks {
+		if _, declared := declaredCities[repeated.link.neighbour]; !declared {
+			addProblem(repeated.link.position, UndeclaredCity, "%q has a road to %q which is not declared", repeated.city, repeated.link.neighbour)
+		}
+	}
+
+	// only one of the line and the city of a problem is set
+	sort.SliceStable(report.Problems, func(i, j int) bool {
+		return report.Problems[i].Line+report.Problems[i].City < report.Problems[j].Line+report.Problems[j].City
+	})
+	report.Passed = len(report.Problems) == 0
+	return report
+}
+
+// isValidCityName checks that a city name is not empty and contains only english letters and dashes
+func isValidCityName(cityName string) bool {
+	if cityName == "" {
+		return false
+	}
+	for _, c := range cityName {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
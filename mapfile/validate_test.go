@@ -0,0 +1,116 @@
+package mapfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// problemKinds returns the line and kind of each problem in the report
+func problemKinds(report *Report) []Problem {
+	kinds := []Problem{}
+	for _, problem := range report.Problems {
+		kinds = append(kinds, Problem{Line: problem.Line, Kind: problem.Kind})
+	}
+	return kinds
+}
+
+func TestValidateMapPasses(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := "Foo north=Bar west=Baz south=Qu-ux\n" +
+		"Bar south=Foo west=Bee\n" +
+		"Baz east=Foo\n" +
+		"Qu-ux north=Foo\n" +
+		"Bee east=Bar\n"
+	report, err := ValidateMap("map.txt", strings.NewReader(contents))
+
+	assert.Nil(err, "Validation should not fail.")
+	assert.True(report.Passed, "A correct map should pass.")
+	assert.Empty(report.Problems, "A correct map should have no problems.")
+}
+
+func TestValidateMapReportsProblems(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := "Foo north=Bar west=Baz east\n" +
+		"Bar south=Foo up=Baz\n" +
+		"Baz east=Foo east=Bar\n" +
+		"Foo north=Bar\n" +
+		"Qu_ux\n" +
+		"Bee north=Quux\n"
+	report, err := ValidateMap("map.txt", strings.NewReader(contents))
+
+	assert.Nil(err, "Validation should not fail.")
+	assert.False(report.Passed, "A broken map should not pass.")
+	assert.Equal([]Problem{
+		{Line: 1, Kind: MalformedLink},
+		{Line: 2, Kind: UnknownDirection},
+		{Line: 3, Kind: DuplicateDirection},
+		{Line: 4, Kind: DuplicateCity},
+		{Line: 5, Kind: IllegalCityName},
+		{Line: 6, Kind: UndeclaredCity},
+	}, problemKinds(report), "Every problem should be reported on its line.")
+	assert.Equal("map.txt:1: malformed-link: road \"east\" of \"Foo\" is not in the direction=City form", report.Problems[0].String())
+}
+
+func TestValidateMapChecksRoadsOfDuplicateCities(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := "Foo north=Bar\n" +
+		"Bar south=Foo\n" +
+		"Foo east west=Bar up=Bar south=Nowhere south=Bar\n"
+	report, err := ValidateMap("map.txt", strings.NewReader(contents))
+
+	assert.Nil(err, "Validation should not fail.")
+	assert.Equal([]Problem{
+		{Line: 3, Kind: DuplicateCity},
+		{Line: 3, Kind: MalformedLink},
+		{Line: 3, Kind: UnknownDirection},
+		{Line: 3, Kind: DuplicateDirection},
+		{Line: 3, Kind: UndeclaredCity},
+	}, problemKinds(report), "Every road of the repeated city should be checked.")
+}
+
+func TestValidateMapReportsNonReciprocalLinks(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := "Foo north=Bar west=Baz\n" +
+		"Bar south=Foo\n" +
+		"Baz west=Foo\n"
+	report, err := ValidateMap("map.txt", strings.NewReader(contents))
+
+	assert.Nil(err, "Validation should not fail.")
+	assert.Equal([]Problem{
+		{Line: 1, Kind: NonReciprocalLink},
+		{Line: 3, Kind: NonReciprocalLink},
+	}, problemKinds(report), "Both ends of the one-way road should be reported.")
+}
+
+func TestValidateMapFileReportsCitiesOfJSONMaps(t *testing.T) {
+	assert := assert.New(t)
+
+	fileName := filepath.Join(t.TempDir(), "bad.json")
+	contents := `{"cities": [{"name": "A", "roads": [{"direction": "up", "to": "B"}, {"direction": "north", "to": "B"}]},` +
+		`{"name": "A"}, {"name": "X Y=Z", "roads": [{"direction": "north", "to": "B"}, {"direction": "north", "to": "C"}]}, {"name": "B"}]}`
+	assert.Nil(os.WriteFile(fileName, []byte(contents), 0644))
+
+	report, err := ValidateMapFile(fileName, "")
+	assert.Nil(err, "Validation should not fail.")
+	kinds := []Problem{}
+	for _, problem := range report.Problems {
+		kinds = append(kinds, Problem{City: problem.City, Kind: problem.Kind})
+	}
+	assert.Equal([]Problem{
+		{City: 1, Kind: UnknownDirection},
+		{City: 1, Kind: NonReciprocalLink},
+		{City: 2, Kind: DuplicateCity},
+		{City: 3, Kind: IllegalCityName},
+		{City: 3, Kind: DuplicateDirection},
+		{City: 3, Kind: NonReciprocalLink},
+	}, kinds, "Every problem should be reported at the index of its city.")
+	assert.Equal(fileName+": city #2: duplicate-city: city \"A\" is already declared as city #1", report.Problems[2].String())
+}
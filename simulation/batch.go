@@ -0,0 +1,205 @@
+package simulation
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
+	"github.com/AleksandarHr/AlienInvasion/utils"
+)
+
+// BatchConfig holds the parameters of a batch of independent simulations
+type BatchConfig struct {
+	// parameters shared by every simulation of the batch; the seed is the base seed
+	// every simulation derives its own seed from
+	Config Config
+
+	// number of simulations to run
+	Runs int
+
+	// number of simulations to run in parallel, the number of CPUs if 0
+	Workers int
+}
+
+// RunSummary holds the outcome of a single simulation of a batch
+type RunSummary struct {
+	Run             int      `json:"run"`
+	Seed            int64    `json:"seed"`
+	StopReason      string   `json:"stopReason"`
+	Iterations      int      `json:"iterations"`
+	SurvivingAliens int      `json:"survivingAliens"`
+	DestroyedCities []string `json:"destroyedCities"`
+	Errors          int      `json:"errors"`
+}
+
+// CityDestruction holds how often a city was destroyed across a batch
+type CityDestruction struct {
+	City        string  `json:"city"`
+	Destroyed   int     `json:"destroyed"`
+	Probability float64 `json:"probability"`
+}
+
+// BatchReport holds the aggregated outcome of a batch of simulations
+type BatchReport struct {
+	Runs    int   `json:"runs"`
+	Workers int   `json:"workers"`
+	Seed    int64 `json:"seed"`
+
+	// number of simulations which stopped for each reason
+	StopReasons map[string]int `json:"stopReasons"`
+
+	// distribution of the number of iterations until the simulations stopped
+	Iterations Distribution `json:"iterations"`
+
+	// distribution of the number of aliens alive at the end of the simulations
+	SurvivingAliens Distribution `json:"survivingAliens"`
+
+	// distribution of the number of cities destroyed during the simulations
+	DestroyedCities Distribution `json:"destroyedCities"`
+
+	// probability of each city being destroyed, from the most to the least likely
+	CityDestruction []CityDestruction `json:"cityDestruction"`
+
+	// outcome of every simulation, ordered by run
+	RunSummaries []RunSummary `json:"runSummaries"`
+}
+
+// DeriveSeeds returns the seeds of the given number of simulations derived from a base seed.
+// The seed of a run depends only on the base seed and its index
+func DeriveSeeds(baseSeed int64, runs int) []int64 {
+	source := utils.CreateRandomSource(baseSeed)
+	seeds := make([]int64, runs)
+	for i := range seeds {
+		seeds[i] = int64(source.Uint64())
+	}
+	return seeds
+}
+
+// RunBatch runs the configured number of independent simulations across a pool of workers,
+// each simulation with its own world and derived seed, and aggregates their outcomes.
+// The map is parsed once and shared by every simulation
+func RunBatch(config BatchConfig) (*BatchReport, error) {
+	if config.Runs < 1 {
+		return nil, &InvalidRunsError{runs: config.Runs}
+	}
+
+	simulationConfig := config.Config
+	if simulationConfig.MapInfo == nil {
+		mapInfo, err := mapfile.LoadMapInfo(simulationConfig.MapFileName, simulationConfig.MapFormat)
+		if err != nil {
+			return nil, err
+		}
+		simulationConfig.MapInfo = mapInfo
+	}
+	// bulk runs are never logged, streamed or checkpointed
+	simulationConfig.Silent = true
+	simulationConfig.EventWriter = nil
+	simulationConfig.CheckpointFileName = ""
+
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	seeds := DeriveSeeds(simulationConfig.Seed, config.Runs)
+	summaries := make([]RunSummary, config.Runs)
+	errs := make([]error, config.Runs)
+
+	runs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for run := range runs {
+				summaries[run], errs[run] = runOnce(simulationConfig, run, seeds[run])
+			}
+		}()
+	}
+	for run := 0; run < config.Runs; run++ {
+		runs <- run
+	}
+	close(runs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return aggregate(simulationConfig, workers, summaries), nil
+}
+
+// runOnce runs a single simulation of a batch with its own world
+func runOnce(config Config, run int, seed int64) (RunSummary, error) {
+	config.Seed = seed
+	simulation, err := CreateSimulation(config)
+	if err != nil {
+		return RunSummary{}, err
+	}
+	// a world the aliens cannot be spawned in fails the whole batch
+	if err := simulation.InitializeSimulation(); err != nil {
+		return RunSummary{}, err
+	}
+	result := simulation.Run(context.Background())
+
+	return RunSummary{
+		Run:             run,
+		Seed:            seed,
+		StopReason:      result.StopReason.String(),
+		Iterations:      result.Iterations,
+		SurvivingAliens: len(result.SurvivingAliens),
+		DestroyedCities: result.DestroyedCities,
+		Errors:          len(result.Errors),
+	}, nil
+}
+
+// aggregate summarizes the outcomes of the simulations of a batch
+func aggregate(config Config, workers int, summaries []RunSummary) *BatchReport {
+	report := &BatchReport{
+		Runs:         len(summaries),
+		Workers:      workers,
+		Seed:         config.Seed,
+		StopReasons:  make(map[string]int),
+		RunSummaries: summaries,
+	}
+
+	destroyedCounts := make(map[string]int)
+	for cityName := range config.MapInfo {
+		destroyedCounts[cityName] = 0
+	}
+
+	iterations := make([]float64, 0, len(summaries))
+	survivors := make([]float64, 0, len(summaries))
+	destroyed := make([]float64, 0, len(summaries))
+	for _, summary := range summaries {
+		report.StopReasons[summary.StopReason]++
+		iterations = append(iterations, float64(summary.Iterations))
+		survivors = append(survivors, float64(summary.SurvivingAliens))
+		destroyed = append(destroyed, float64(len(summary.DestroyedCities)))
+		for _, cityName := range summary.DestroyedCities {
+			destroyedCounts[cityName]++
+		}
+	}
+	report.Iterations = summarizeDistribution(iterations)
+	report.SurvivingAliens = summarizeDistribution(survivors)
+	report.DestroyedCities = summarizeDistribution(destroyed)
+
+	report.CityDestruction = []CityDestruction{}
+	for cityName, count := range destroyedCounts {
+		probability := 0.0
+		if len(summaries) > 0 {
+			probability = float64(count) / float64(len(summaries))
+		}
+		report.CityDestruction = append(report.CityDestruction, CityDestruction{City: cityName, Destroyed: count, Probability: probability})
+	}
+	sort.Slice(report.CityDestruction, func(i, j int) bool {
+		if report.CityDestruction[i].Destroyed != report.CityDestruction[j].Destroyed {
+			return report.CityDestruction[i].Destroyed > report.CityDestruction[j].Destroyed
+		}
+		return report.CityDestruction[i].City < report.CityDestruction[j].City
+	})
+	return report
+}
@@ -0,0 +1,70 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	config := BatchConfig{
+		Config: Config{AliensCount: 3, MapFileName: writeMapFile(t, replayTestMap), MaxIterations: 50, Seed: 42},
+		Runs:   40,
+	}
+
+	config.Workers = 1
+	sequential, err := RunBatch(config)
+	assert.Nil(err, "Running the batch should not fail.")
+	config.Workers = 4
+	parallel, err := RunBatch(config)
+	assert.Nil(err, "Running the batch should not fail.")
+
+	assert.Equal(40, sequential.Runs, "Every run should be reported.")
+	assert.Equal(sequential.RunSummaries, parallel.RunSummaries, "Runs should not depend on the number of workers.")
+	assert.Equal(sequential.StopReasons, parallel.StopReasons, "Stop reasons should not depend on the number of workers.")
+
+	stopped := 0
+	for _, count := range sequential.StopReasons {
+		stopped += count
+	}
+	assert.Equal(40, stopped, "Every run should have a stop reason.")
+	assert.Equal(5, len(sequential.CityDestruction), "Every city should have a destruction probability.")
+	for _, city := range sequential.CityDestruction {
+		assert.InDelta(float64(city.Destroyed)/40, city.Probability, 1e-9, "Probabilities should be the share of runs destroying the city.")
+	}
+
+	seeds := map[int64]bool{}
+	for _, summary := range sequential.RunSummaries {
+		seeds[summary.Seed] = true
+	}
+	assert.Equal(40, len(seeds), "Every run should have its own seed.")
+}
+
+func TestRunBatchRejectsNoRuns(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, runs := range []int{0, -3} {
+		_, err := RunBatch(BatchConfig{Config: Config{AliensCount: 3, MapFileName: writeMapFile(t, replayTestMap), MaxIterations: 50}, Runs: runs})
+		assert.IsType(&InvalidRunsError{}, err, "A batch of %d runs should be rejected.", runs)
+	}
+}
+
+func TestSummarizeDistribution(t *testing.T) {
+	assert := assert.New(t)
+
+	values := []float64{}
+	for i := 100; i >= 1; i-- {
+		values = append(values, float64(i))
+	}
+	distribution := summarizeDistribution(values)
+
+	assert.Equal(1.0, distribution.Min)
+	assert.Equal(100.0, distribution.Max)
+	assert.Equal(50.5, distribution.Mean)
+	assert.Equal(50.0, distribution.P50)
+	assert.Equal(90.0, distribution.P90)
+	assert.Equal(99.0, distribution.P99)
+	assert.Equal(Distribution{}, summarizeDistribution(nil), "No values should give an empty distribution.")
+}
@@ -0,0 +1,292 @@
+package simulation
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/AleksandarHr/AlienInvasion/utils"
+)
+
+// version of the checkpoint format, increased whenever the format changes
+const CheckpointVersion = 5
+
+// Checkpoint holds the full state of a simulation, possibly in the middle of an iteration
+type Checkpoint struct {
+	Version int `json:"version"`
+
+	AliensCount   int   `json:"aliensCount"`
+	MaxIterations int   `json:"maxIterations"`
+	Seed          int64 `json:"seed"`
+
+	// number of fully simulated iterations
+	Iteration int `json:"iteration"`
+
+	// IDs of the aliens still to move in the iteration in progress, in order, empty between iterations
+	PendingAliens []int `json:"pendingAliens,omitempty"`
+
+	Stage structs.SimulationStage `json:"stage"`
+
+	// state of the random source
+	RandomState uint64 `json:"randomState"`
+
+	World *structs.WorldSnapshot `json:"world"`
+
+	// names of the movement strategies, uniformly random moves if empty
+	MovementStrategy string         `json:"movementStrategy,omitempty"`
+	AlienStrategies  map[int]string `json:"alienStrategies,omitempty"`
+
+	// name of the fight resolver, mutual destruction if empty
+	FightResolver string `json:"fightResolver,omitempty"`
+
+	// name of the movement mode, sequential if empty
+	Movement string `json:"movement,omitempty"`
+
+	// name of the spawn strategy, uniformly random if empty, along with the placements of the aliens
+	// if they spawn where they are placed
+	SpawnStrategy string         `json:"spawnStrategy,omitempty"`
+	Placements    map[int]string `json:"placements,omitempty"`
+
+	// number of aliens spawned so far, along with the waves of aliens and their maximum number, if any
+	SpawnedAliens int    `json:"spawnedAliens"`
+	Waves         string `json:"waves,omitempty"`
+	MaxAliens     int    `json:"maxAliens,omitempty"`
+
+	// options of the DOT renderings, which the resumed run keeps rendering with
+	DOTOptions structs.DOTOptions `json:"dotOptions"`
+
+	// the outcome of the simulation so far
+	DestroyedCities []string `json:"destroyedCities"`
+	Fights          []Fight  `json:"fights"`
+	Errors          []string `json:"errors"`
+}
+
+// Checkpoint captures the full state of the simulation
+func (s *Simulation) Checkpoint() *Checkpoint {
+	checkpoint := &Checkpoint{
+		Version:          CheckpointVersion,
+		AliensCount:      s.initialAliensCount,
+		MaxIterations:    s.maxIterations,
+		Seed:             s.seed,
+		Iteration:        s.iteration,
+		Stage:            s.stage,
+		RandomState:      s.source.State(),
+		World:            s.world.Snapshot(),
+		MovementStrategy: s.movementStrategy.String(),
+		FightResolver:    s.world.FightResolver().String(),
+		Movement:         s.movement.String(),
+		SpawnStrategy:    s.spawnStrategy.String(),
+		SpawnedAliens:    s.spawnedAliens,
+		DOTOptions:       s.dotOptions,
+		DestroyedCities:  s.result.DestroyedCities,
+		Fights:           s.result.Fights,
+		Errors:           []string{},
+	}
+	for _, alien := range s.pendingAliens {
+		checkpoint.PendingAliens = append(checkpoint.PendingAliens, alien.ID)
+	}
+	for _, err := range s.result.Errors {
+		checkpoint.Errors = append(checkpoint.Errors, err.Error())
+	}
+	if placement, placed := s.spawnStrategy.(structs.PlacementSpawnStrategy); placed {
+		checkpoint.Placements = placement.Placements
+	}
+	if s.waves != nil {
+		checkpoint.Waves = s.waves.String()
+		checkpoint.MaxAliens = s.waves.MaxAliens
+	}
+	if len(s.alienStrategies) > 0 {
+		checkpoint.AlienStrategies = make(map[int]string)
+		for alienID, strategy := range s.alienStrategies {
+			checkpoint.AlienStrategies[alienID] = strategy.String()
+		}
+	}
+	return checkpoint
+}
+
+// WriteCheckpoint writes the full state of the simulation as JSON
+func (s *Simulation) WriteCheckpoint(out io.Writer) error {
+	return json.NewEncoder(out).Encode(s.Checkpoint())
+}
+
+// SaveCheckpoint writes the full state of the simulation to the given file. The file is replaced
+// only once the checkpoint is complete, so a previous checkpoint survives a failed write
+func (s *Simulation) SaveCheckpoint(fileName string) error {
+	temp, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if err := s.WriteCheckpoint(temp); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), fileName)
+}
+
+// saveCheckpoint writes a checkpoint to the configured file, if any, recording any failure
+func (s *Simulation) saveCheckpoint() {
+	if s.checkpointFileName == "" {
+		return
+	}
+	if err := s.SaveCheckpoint(s.checkpointFileName); err != nil {
+		s.defaultLogger.Err(err).Msgf("Unable to save checkpoint to %s.", s.checkpointFileName)
+		s.debugLogger.Err(err).Msgf("Unable to save checkpoint to %s.", s.checkpointFileName)
+		s.result.Errors = append(s.result.Errors, err)
+		return
+	}
+	s.debugLogger.Info().Msgf("Saved checkpoint after %d iterations to %s.", s.iteration, s.checkpointFileName)
+}
+
+// restorePendingAliens resumes the iteration in progress with the aliens still to move in it.
+// Aliens which died earlier in the iteration are no longer in the world and would not have moved
+func (s *Simulation) restorePendingAliens(alienIDs []int) {
+	if len(alienIDs) == 0 {
+		return
+	}
+	aliens := make(map[int]*structs.Alien)
+	alive, _ := s.world.GetAllAliens()
+	for _, alien := range alive {
+		aliens[alien.ID] = alien
+	}
+	s.inIteration = true
+	s.pendingAliens = []*structs.Alien{}
+	for _, alienID := range alienIDs {
+		if alien, exists := aliens[alienID]; exists {
+			s.pendingAliens = append(s.pendingAliens, alien)
+		}
+	}
+}
+
+// strategies parses the movement strategies saved in the checkpoint
+func (checkpoint *Checkpoint) strategies() (structs.MovementStrategy, map[int]structs.MovementStrategy, error) {
+	var movementStrategy structs.MovementStrategy
+	if checkpoint.MovementStrategy != "" {
+		var err error
+		if movementStrategy, err = structs.ParseMovementStrategy(checkpoint.MovementStrategy); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	alienStrategies := make(map[int]structs.MovementStrategy)
+	for alienID, name := range checkpoint.AlienStrategies {
+		strategy, err := structs.ParseMovementStrategy(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		alienStrategies[alienID] = strategy
+	}
+	return movementStrategy, alienStrategies, nil
+}
+
+// spawning parses the spawn strategy and the waves of aliens saved in the checkpoint
+func (checkpoint *Checkpoint) spawning() (structs.SpawnStrategy, *WaveSchedule, error) {
+	var spawnStrategy structs.SpawnStrategy = structs.UniformSpawnStrategy{}
+	if checkpoint.Placements != nil {
+		spawnStrategy = structs.PlacementSpawnStrategy{Placements: checkpoint.Placements}
+	} else if checkpoint.SpawnStrategy != "" {
+		var err error
+		if spawnStrategy, err = structs.ParseSpawnStrategy(checkpoint.SpawnStrategy); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if checkpoint.Waves == "" {
+		return spawnStrategy, nil, nil
+	}
+	waves, err := ParseWaveSchedule(checkpoint.Waves, checkpoint.MaxAliens)
+	if err != nil {
+		return nil, nil, err
+	}
+	return spawnStrategy, waves, nil
+}
+
+// ResumeSimulation restores a simulation from a checkpoint read from the reader. The aliens count,
+// maximum iterations, seed, spawn strategy, waves, movement mode and strategies and fight resolver come from the checkpoint, while the event stream and further
+// checkpoints are set up from the config. The DOT renderings omit destroyed cities if either the checkpoint or
+// the config asks for it. Resuming continues exactly like the interrupted run would have
+func ResumeSimulation(in io.Reader, config Config) (*Simulation, error) {
+	var checkpoint Checkpoint
+	if err := json.NewDecoder(in).Decode(&checkpoint); err != nil {
+		return nil, err
+	}
+	if checkpoint.Version != CheckpointVersion {
+		return nil, &CheckpointVersionError{version: checkpoint.Version}
+	}
+	if checkpoint.World == nil {
+		return nil, errors.New("Checkpoint does not contain a world.")
+	}
+
+	world, err := structs.RestoreWorld(checkpoint.World)
+	if err != nil {
+		return nil, err
+	}
+
+	spawnStrategy, waves, err := checkpoint.spawning()
+	if err != nil {
+		return nil, err
+	}
+	movementStrategy, alienStrategies, err := checkpoint.strategies()
+	if err != nil {
+		return nil, err
+	}
+	var fightResolver structs.FightResolver
+	if checkpoint.FightResolver != "" {
+		if fightResolver, err = structs.ParseFightResolver(checkpoint.FightResolver); err != nil {
+			return nil, err
+		}
+	}
+
+	movement := Sequential
+	if checkpoint.Movement != "" {
+		if movement, err = ParseMovementMode(checkpoint.Movement); err != nil {
+			return nil, err
+		}
+	}
+
+	defaultLog, debugLog := initializeLoggers(config)
+	source := utils.CreateRandomSource(checkpoint.Seed)
+	source.SetState(checkpoint.RandomState)
+
+	simulation := &Simulation{
+		initialAliensCount: checkpoint.AliensCount,
+		spawnStrategy:      spawnStrategy,
+		waves:              waves,
+		spawnedAliens:      checkpoint.SpawnedAliens,
+		maxIterations:      checkpoint.MaxIterations,
+		seed:               checkpoint.Seed,
+		rng:                rand.New(source),
+		source:             source,
+		world:              world,
+		defaultLogger:      defaultLog,
+		debugLogger:        debugLog,
+		stage:              checkpoint.Stage,
+		iteration:          checkpoint.Iteration,
+		movement:           movement,
+		result: &SimulationResult{
+			StopReason:      NotStopped,
+			DestroyedCities: checkpoint.DestroyedCities,
+			Fights:          checkpoint.Fights,
+		},
+	}
+	for _, message := range checkpoint.Errors {
+		simulation.result.Errors = append(simulation.result.Errors, errors.New(message))
+	}
+	simulation.restorePendingAliens(checkpoint.PendingAliens)
+	config.DOTOptions.OmitDestroyed = config.DOTOptions.OmitDestroyed || checkpoint.DOTOptions.OmitDestroyed
+	simulation.attachOutputs(config)
+	simulation.setStrategies(movementStrategy, alienStrategies)
+	simulation.setFightResolver(fightResolver)
+
+	simulation.defaultLogger.Info().Msgf("Resuming simulation with seed %d after %d iterations.", simulation.seed, simulation.iteration)
+	simulation.debugLogger.Info().Msgf("Resuming simulation with seed %d after %d iterations.", simulation.seed, simulation.iteration)
+	return simulation, nil
+}
@@ -0,0 +1,217 @@
+package simulation
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+const checkpointTestMap = "A east=B south=C\n" +
+	"B west=A south=D east=E\n" +
+	"C north=A east=D\n" +
+	"D north=B west=C east=F\n" +
+	"E west=B south=F\n" +
+	"F north=E west=D\n"
+
+// summarize returns the comparable parts of a result along with the remaining world
+func summarize(result *SimulationResult) (StopReason, int, []string, []Fight, []AlienInfo, string) {
+	var remainingWorld strings.Builder
+	result.World.WriteMap(&remainingWorld)
+	return result.StopReason, result.Iterations, result.DestroyedCities, result.Fights, result.SurvivingAliens, remainingWorld.String()
+}
+
+func TestResumeSimulation(t *testing.T) {
+	assert := assert.New(t)
+
+	mapFileName := writeMapFile(t, checkpointTestMap)
+	// the waves, the movement mode and strategies, including the remembered previous cities, and the fight resolver must be restored as well
+	waves, _ := ParseWaveSchedule("1@2/4", 6)
+	for _, strategies := range []struct {
+		mode     MovementMode
+		movement structs.MovementStrategy
+		aliens   map[int]structs.MovementStrategy
+		resolver structs.FightResolver
+		waves    *WaveSchedule
+	}{
+		{Sequential, nil, nil, nil, nil},
+		{Sequential, structs.NonBacktrackingStrategy{}, map[int]structs.MovementStrategy{1: structs.LazyStrategy{StayProbability: 0.3}}, structs.ProbabilisticResolver{SurvivalProbability: 0.5}, nil},
+		{Sequential, nil, nil, structs.ThresholdResolver{Threshold: 3}, nil},
+		{Simultaneous, structs.LazyStrategy{StayProbability: 0.3}, nil, structs.WinnerTakesCityResolver{}, nil},
+		{Sequential, nil, nil, nil, waves},
+	} {
+		for seed := int64(0); seed < 10; seed++ {
+			config := Config{AliensCount: 3, MapFileName: mapFileName, MaxIterations: 40, Seed: seed,
+				Waves: strategies.waves, Movement: strategies.mode, MovementStrategy: strategies.movement, AlienStrategies: strategies.aliens, FightResolver: strategies.resolver}
+
+			uninterrupted, err := CreateSimulation(config)
+			if err != nil {
+				t.Fatalf("Creating simulation failed: %v", err)
+			}
+			uninterrupted.InitializeSimulation()
+			expectedReason, expectedIterations, expectedDestroyed, expectedFights, expectedAliens, expectedWorld := summarize(uninterrupted.Run(context.Background()))
+
+			// checkpoint every few iterations, then resume from the last checkpoint
+			checkpointFileName := filepath.Join(t.TempDir(), "checkpoint.json")
+			config.CheckpointEvery = 3
+			config.CheckpointFileName = checkpointFileName
+			checkpointed, err := CreateSimulation(config)
+			if err != nil {
+				t.Fatalf("Creating simulation failed: %v", err)
+			}
+			checkpointed.InitializeSimulation()
+			checkpointed.Run(context.Background())
+
+			checkpointFile, err := os.Open(checkpointFileName)
+			if err != nil {
+				// the run ended before the first checkpoint
+				continue
+			}
+			resumed, err := ResumeSimulation(checkpointFile, Config{})
+			checkpointFile.Close()
+			assert.Nil(err, "Resuming from a checkpoint should not fail.")
+
+			reason, iterations, destroyed, fights, aliens, world := summarize(resumed.Run(context.Background()))
+			assert.Equal(expectedReason, reason, "The resumed run should stop for the same reason.")
+			assert.Equal(expectedIterations, iterations, "The resumed run should stop after the same iterations.")
+			assert.Equal(expectedDestroyed, destroyed, "The resumed run should destroy the same cities.")
+			assert.Equal(expectedFights, fights, "The resumed run should have the same fights.")
+			assert.Equal(expectedAliens, aliens, "The resumed run should leave the same aliens.")
+			assert.Equal(expectedWorld, world, "The resumed run should leave the same world.")
+		}
+	}
+}
+
+func TestCancelBeforeRunSavesCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	checkpointFileName := filepath.Join(t.TempDir(), "checkpoint.json")
+	simulation, err := CreateSimulation(Config{
+		AliensCount:        1,
+		MapFileName:        writeMapFile(t, checkpointTestMap),
+		MaxIterations:      40,
+		CheckpointFileName: checkpointFileName,
+	})
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	simulation.InitializeSimulation()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := simulation.Run(ctx)
+
+	assert.Equal(Cancelled, result.StopReason, "The simulation should have been cancelled.")
+	assert.Equal(0, result.Iterations, "No iteration should run once the simulation is cancelled.")
+	assert.FileExists(checkpointFileName, "A checkpoint should be saved on cancellation.")
+}
+
+// cancellingObserver cancels the simulation after the given number of moves
+type cancellingObserver struct {
+	BaseObserver
+
+	moves  int
+	cancel context.CancelFunc
+}
+
+func (observer *cancellingObserver) OnMove(event structs.Event) {
+	observer.moves--
+	if observer.moves == 0 {
+		observer.cancel()
+	}
+}
+
+func TestCancelSavesCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	mapFileName := writeMapFile(t, checkpointTestMap)
+	midIteration := 0
+	for seed := int64(0); seed < 10; seed++ {
+		config := Config{AliensCount: 3, MapFileName: mapFileName, MaxIterations: 40, Seed: seed}
+		uninterrupted, err := CreateSimulation(config)
+		if err != nil {
+			t.Fatalf("Creating simulation failed: %v", err)
+		}
+		uninterrupted.InitializeSimulation()
+		expectedReason, expectedIterations, expectedDestroyed, expectedFights, expectedAliens, expectedWorld := summarize(uninterrupted.Run(context.Background()))
+
+		// cancel right after the second move
+		config.CheckpointFileName = filepath.Join(t.TempDir(), "checkpoint.json")
+		cancelled, err := CreateSimulation(config)
+		if err != nil {
+			t.Fatalf("Creating simulation failed: %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		observer := &cancellingObserver{moves: 2, cancel: cancel}
+		cancelled.AddObserver(observer)
+		cancelled.InitializeSimulation()
+		result := cancelled.Run(ctx)
+		cancel()
+		if result.StopReason != Cancelled {
+			// the run ended before the second move
+			continue
+		}
+		assert.Equal(0, observer.moves, "No alien should move once the simulation is cancelled.")
+		assert.NotNil(result.World, "A cancelled simulation should return the world so far.")
+
+		checkpointFile, err := os.Open(config.CheckpointFileName)
+		if err != nil {
+			t.Fatalf("A checkpoint should be saved on cancellation: %v", err)
+		}
+		var checkpoint Checkpoint
+		json.NewDecoder(checkpointFile).Decode(&checkpoint)
+		if len(checkpoint.PendingAliens) > 0 {
+			midIteration++
+		}
+		checkpointFile.Seek(0, io.SeekStart)
+		resumed, err := ResumeSimulation(checkpointFile, Config{})
+		checkpointFile.Close()
+		assert.Nil(err, "Resuming from a checkpoint should not fail.")
+
+		reason, iterations, destroyed, fights, aliens, world := summarize(resumed.Run(context.Background()))
+		assert.Equal(expectedReason, reason, "The resumed run should stop for the same reason.")
+		assert.Equal(expectedIterations, iterations, "The resumed run should stop after the same iterations.")
+		assert.Equal(expectedDestroyed, destroyed, "The resumed run should destroy the same cities.")
+		assert.Equal(expectedFights, fights, "The resumed run should have the same fights.")
+		assert.Equal(expectedAliens, aliens, "The resumed run should leave the same aliens.")
+		assert.Equal(expectedWorld, world, "The resumed run should leave the same world.")
+	}
+	assert.NotZero(midIteration, "Some simulations should be cancelled in the middle of an iteration.")
+}
+
+func TestResumeSimulationRejectsUnknownVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := ResumeSimulation(strings.NewReader(`{"version":999}`), Config{})
+	assert.IsType(&CheckpointVersionError{}, err, "Unknown checkpoint versions should be rejected.")
+}
+
+func TestResumeSimulationKeepsDOTOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	config := Config{AliensCount: 2, MapFileName: writeMapFile(t, checkpointTestMap), MaxIterations: 40, DOTOptions: structs.DOTOptions{OmitDestroyed: true}}
+	simulation, err := CreateSimulation(config)
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	simulation.InitializeSimulation()
+	var checkpoint strings.Builder
+	assert.Nil(simulation.WriteCheckpoint(&checkpoint))
+
+	resumed, err := ResumeSimulation(strings.NewReader(checkpoint.String()), Config{})
+	assert.Nil(err, "Resuming from a checkpoint should not fail.")
+	assert.True(resumed.dotOptions.OmitDestroyed, "The resumed run should keep leaving destroyed cities out.")
+
+	config.DOTOptions = structs.DOTOptions{}
+	simulation, _ = CreateSimulation(config)
+	simulation.InitializeSimulation()
+	checkpoint.Reset()
+	simulation.WriteCheckpoint(&checkpoint)
+	resumed, _ = ResumeSimulation(strings.NewReader(checkpoint.String()), Config{DOTOptions: structs.DOTOptions{OmitDestroyed: true}})
+	assert.True(resumed.dotOptions.OmitDestroyed, "The resumed run should leave destroyed cities out when asked to.")
+}
@@ -0,0 +1,96 @@
+package simulation
+
+import (
+	"fmt"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+)
+
+// error triggered when a recorded event cannot have happened in the replayed world
+type IllegalReplayEventError struct {
+	index  int
+	event  structs.Event
+	reason string
+}
+
+func (err *IllegalReplayEventError) Error() string {
+	return fmt.Sprintf("Illegal event #%d (%s in iteration %d): %s.\n", err.index, err.event.Type, err.event.Iteration, err.reason)
+}
+
+// error triggered when the replayed world produces different events than the recorded ones
+type ReplayDivergenceError struct {
+	index    int
+	recorded *structs.Event
+	replayed *structs.Event
+}
+
+func (err *ReplayDivergenceError) Error() string {
+	describe := func(event *structs.Event) string {
+		if event == nil {
+			return "nothing"
+		}
+		description := event.Type.String()
+		if len(event.AlienIDs) != 0 {
+			description += fmt.Sprintf(" of aliens %v", event.AlienIDs)
+		}
+		if event.City != "" {
+			description += " in " + event.City
+		}
+		return description
+	}
+	iteration := 0
+	if err.recorded != nil {
+		iteration = err.recorded.Iteration
+	} else if err.replayed != nil {
+		iteration = err.replayed.Iteration
+	}
+	return fmt.Sprintf("Replay diverged at event #%d in iteration %d: recorded %s, replayed %s.\n",
+		err.index, iteration, describe(err.recorded), describe(err.replayed))
+}
+
+// error triggered when replaying a recording with another fight resolver than the one it was recorded with
+type FightResolverMismatchError struct {
+	given    string
+	recorded string
+}
+
+func (err *FightResolverMismatchError) Error() string {
+	return fmt.Sprintf("The fight resolver %s does not match the resolver %s the events were recorded with.\n", err.given, err.recorded)
+}
+
+// error triggered when reading a checkpoint written in an unsupported format
+type CheckpointVersionError struct {
+	version int
+}
+
+func (err *CheckpointVersionError) Error() string {
+	return fmt.Sprintf("Unsupported checkpoint version %d, expected version %d.\n", err.version, CheckpointVersion)
+}
+
+// error triggered when running a batch of fewer than one simulation
+type InvalidRunsError struct {
+	runs int
+}
+
+func (err *InvalidRunsError) Error() string {
+	return fmt.Sprintf("Invalid number of runs %d, at least one simulation must run.\n", err.runs)
+}
+
+// error triggered when parsing an unknown movement mode
+type InvalidMovementModeError struct {
+	name string
+}
+
+func (err *InvalidMovementModeError) Error() string {
+	return fmt.Sprintf("Invalid movement mode %q, expected sequential or simultaneous.\n", err.name)
+}
+
+// error triggered when parsing a malformed schedule of waves
+type InvalidWaveScheduleError struct {
+	spec   string
+	reason string
+}
+
+func (err *InvalidWaveScheduleError) Error() string {
+	return fmt.Sprintf("Invalid waves %q: %s.\n", err.spec, err.reason)
+}
@@ -0,0 +1,32 @@
+package simulation
+
+type MovementMode int64
+
+// enum to represent the ways the aliens move during an iteration
+const (
+	// aliens move one at a time, in a random order
+	Sequential MovementMode = iota
+	// every alien picks its destination first and all the moves happen at once
+	Simultaneous
+)
+
+// String returns a representation of the given movement mode
+func (mode MovementMode) String() string {
+	switch mode {
+	case Sequential:
+		return "sequential"
+	case Simultaneous:
+		return "simultaneous"
+	}
+	return "invalid"
+}
+
+// ParseMovementMode returns the movement mode with the given name
+func ParseMovementMode(name string) (MovementMode, error) {
+	for mode := Sequential; mode <= Simultaneous; mode++ {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+	return Sequential, &InvalidMovementModeError{name: name}
+}
@@ -0,0 +1,71 @@
+package simulation
+
+import "github.com/AleksandarHr/AlienInvasion/structs"
+
+// Observer is notified of what happens in a simulation as it happens. Every event is stamped with
+// its iteration, as when it is written to the event writer
+type Observer interface {
+	// OnSpawn is called when an alien spawns, before it fights any alien already in the city
+	OnSpawn(event structs.Event)
+
+	// OnMove is called when an alien moves, before it fights any alien in its destination
+	OnMove(event structs.Event)
+
+	// OnFight is called when aliens fight, in a city or on a road
+	OnFight(event structs.Event)
+
+	// OnCityDestroyed is called when a city is destroyed
+	OnCityDestroyed(event structs.Event)
+
+	// OnRoadRemoved is called when a road is removed, once for each of its directions
+	OnRoadRemoved(event structs.Event)
+
+	// OnTrapped is called when an alien is left in a city without roads
+	OnTrapped(event structs.Event)
+
+	// OnIterationEnd is called once the given iteration is complete
+	OnIterationEnd(iteration int, world *structs.World)
+
+	// OnEnd is called once the simulation has stopped, with its outcome
+	OnEnd(result *SimulationResult)
+}
+
+// BaseObserver ignores every notification. Observers interested in a few of them can embed it
+// and implement only those
+type BaseObserver struct{}
+
+func (BaseObserver) OnSpawn(event structs.Event)                        {}
+func (BaseObserver) OnMove(event structs.Event)                         {}
+func (BaseObserver) OnFight(event structs.Event)                        {}
+func (BaseObserver) OnCityDestroyed(event structs.Event)                {}
+func (BaseObserver) OnRoadRemoved(event structs.Event)                  {}
+func (BaseObserver) OnTrapped(event structs.Event)                      {}
+func (BaseObserver) OnIterationEnd(iteration int, world *structs.World) {}
+func (BaseObserver) OnEnd(result *SimulationResult)                     {}
+
+// AddObserver registers an observer notified of everything happening in the simulation from then
+// on. Observers interested in the aliens spawned before the first iteration are added before
+// InitializeSimulation
+func (s *Simulation) AddObserver(observer Observer) {
+	s.observers = append(s.observers, observer)
+}
+
+// notifyEvent passes the event to the observers, in the order they were added
+func (s *Simulation) notifyEvent(event structs.Event) {
+	for _, observer := range s.observers {
+		switch event.Type {
+		case structs.AlienSpawned:
+			observer.OnSpawn(event)
+		case structs.AlienMoved:
+			observer.OnMove(event)
+		case structs.FightOccurred:
+			observer.OnFight(event)
+		case structs.CityDestroyed:
+			observer.OnCityDestroyed(event)
+		case structs.RoadRemoved:
+			observer.OnRoadRemoved(event)
+		case structs.AlienTrapped:
+			observer.OnTrapped(event)
+		}
+	}
+}
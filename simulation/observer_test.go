@@ -0,0 +1,73 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingObserver counts the notifications it receives
+type countingObserver struct {
+	BaseObserver
+
+	spawns     int
+	fights     []structs.Event
+	destroyed  []string
+	iterations []int
+	results    []*SimulationResult
+}
+
+func (observer *countingObserver) OnSpawn(event structs.Event) {
+	observer.spawns++
+}
+
+func (observer *countingObserver) OnFight(event structs.Event) {
+	observer.fights = append(observer.fights, event)
+}
+
+func (observer *countingObserver) OnCityDestroyed(event structs.Event) {
+	observer.destroyed = append(observer.destroyed, event.City)
+}
+
+func (observer *countingObserver) OnIterationEnd(iteration int, world *structs.World) {
+	observer.iterations = append(observer.iterations, iteration)
+}
+
+func (observer *countingObserver) OnEnd(result *SimulationResult) {
+	observer.results = append(observer.results, result)
+}
+
+func TestObservers(t *testing.T) {
+	assert := assert.New(t)
+
+	mapFileName := writeMapFile(t, "Foo north=Bar west=Baz south=Qu-ux\n"+
+		"Bar south=Foo west=Bee\n"+
+		"Baz east=Foo\n"+
+		"Qu-ux north=Foo\n"+
+		"Bee east=Bar\n")
+	for seed := int64(0); seed < 10; seed++ {
+		simulation, err := CreateSimulation(Config{AliensCount: 4, MapFileName: mapFileName, MaxIterations: 50, Seed: seed})
+		if err != nil {
+			t.Fatalf("Creating simulation failed: %v", err)
+		}
+		observer := &countingObserver{}
+		simulation.AddObserver(observer)
+		simulation.InitializeSimulation()
+		result := simulation.Run(context.Background())
+
+		assert.Equal(4, observer.spawns, "Every spawn should be observed.")
+		assert.Equal(len(result.Fights), len(observer.fights), "Every fight should be observed.")
+		for i, fight := range observer.fights {
+			assert.Equal(result.Fights[i].City, fight.City)
+			assert.Equal(result.Fights[i].Iteration, fight.Iteration, "Fights should be stamped with their iteration.")
+		}
+		assert.Equal(result.DestroyedCities, observer.destroyed, "Every destroyed city should be observed.")
+		assert.Equal(result.Iterations, len(observer.iterations), "The end of every iteration should be observed.")
+		for i, iteration := range observer.iterations {
+			assert.Equal(i, iteration, "Iterations should end in order.")
+		}
+		assert.Equal([]*SimulationResult{result}, observer.results, "The end of the simulation should be observed once.")
+	}
+}
@@ -0,0 +1,45 @@
+package simulation
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DOTFileNameAt returns the name of the file the world is rendered to after the given iteration,
+// the iteration (or "start" for -1, right after spawning) being added before the extension
+func DOTFileNameAt(fileName string, iteration int) string {
+	moment := strconv.Itoa(iteration)
+	if iteration == -1 {
+		moment = "start"
+	}
+	extension := filepath.Ext(fileName)
+	return strings.TrimSuffix(fileName, extension) + "-" + moment + extension
+}
+
+// renderIteration renders the world to its DOT file if it was requested after the given iteration
+func (s *Simulation) renderIteration(iteration int) {
+	if s.dotFileName == "" || !s.dotIterations[iteration] {
+		return
+	}
+	s.renderDOT(DOTFileNameAt(s.dotFileName, iteration))
+}
+
+// renderDOT renders the world as a DOT graph to the given file
+func (s *Simulation) renderDOT(fileName string) {
+	file, err := os.Create(fileName)
+	if err == nil {
+		err = s.world.WriteDOT(file, s.dotOptions)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		s.defaultLogger.Err(err).Msgf("Unable to render the world to %s.", fileName)
+		s.debugLogger.Err(err).Msgf("Unable to render the world to %s.", fileName)
+		s.result.Errors = append(s.result.Errors, err)
+		return
+	}
+	s.debugLogger.Info().Msgf("Rendered the world after %d iterations to %s.", s.iteration, fileName)
+}
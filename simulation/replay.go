@@ -0,0 +1,364 @@
+package simulation
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"reflect"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+)
+
+// Replayer rebuilds a world step by step from the events recorded during a simulation,
+// checking that every recorded step was legal and had the recorded consequences
+type Replayer struct {
+	world *structs.World
+
+	// aliens which have been spawned, dead or alive
+	aliens map[int]*structs.Alien
+
+	// events produced by the replayed world which have not been matched to recorded events yet
+	replayed []structs.Event
+
+	// number of recorded events applied so far
+	applied int
+
+	// iteration of the last applied event
+	iteration int
+
+	// whether the recorded simulation has ended
+	ended bool
+
+	// last recorded step, applied once all of its recorded consequences are known
+	step *replayStep
+
+	// recorded consequences of the last step
+	consequences []recordedEvent
+
+	// resolver of the recorded simulation, deciding whether aliens sharing a city fight
+	resolver structs.FightResolver
+}
+
+// replayStep holds a spawn, a move or a batch of simultaneous moves
+type replayStep struct {
+	moves        []structs.AlienMove
+	simultaneous bool
+}
+
+// recordedEvent holds a recorded event along with its index in the recording
+type recordedEvent struct {
+	index int
+	event structs.Event
+}
+
+// CreateReplayer constructs a replayer for a world created from the provided map information.
+// The fight resolver of the recorded simulation, mutual destruction if nil, decides whether
+// aliens meeting in a city fight, while the outcome of every fight is taken from the recording
+func CreateReplayer(mapInfo map[string][]string, resolver structs.FightResolver) *Replayer {
+	world := structs.CreateWorld()
+	world.InitializeWorld(mapInfo)
+	if resolver == nil {
+		resolver = structs.MutualDestructionResolver{}
+	}
+	world.SetFightResolver(resolver, rand.New(rand.NewSource(0)))
+
+	replayer := &Replayer{
+		world:     world,
+		aliens:    make(map[int]*structs.Alien),
+		iteration: -1,
+		resolver:  resolver,
+	}
+	world.SetEventRecorder(structs.EventRecorderFunc(func(event structs.Event) {
+		event.Iteration = replayer.iteration
+		replayer.replayed = append(replayer.replayed, event)
+	}))
+	return replayer
+}
+
+// World returns the replayed world
+func (r *Replayer) World() *structs.World {
+	return r.world
+}
+
+// Iteration returns the iteration of the last applied event, -1 while spawning aliens
+func (r *Replayer) Iteration() int {
+	return r.iteration
+}
+
+// Ended checks whether the recorded simulation has ended
+func (r *Replayer) Ended() bool {
+	return r.ended
+}
+
+// Apply replays the next recorded event. Spawns and moves are checked to be legal and applied
+// to the world along with the next spawn or move, once their recorded consequences (fights,
+// destroyed cities and roads, trapped aliens) are known, so that fights decided at random are
+// given the recorded outcomes. The consequences are then checked against what the replayed world
+// produced. Simultaneous moves of an iteration are applied together as a single step
+func (r *Replayer) Apply(event structs.Event) error {
+	index := r.applied
+	r.applied++
+
+	if r.ended {
+		return &IllegalReplayEventError{index: index, event: event, reason: "the simulation has already ended"}
+	}
+
+	switch event.Type {
+	case structs.FightOccurred, structs.CityDestroyed, structs.RoadRemoved, structs.AlienTrapped:
+		if r.step == nil {
+			return &ReplayDivergenceError{index: index, recorded: &event}
+		}
+		r.consequences = append(r.consequences, recordedEvent{index: index, event: event})
+		return nil
+	}
+
+	// a simultaneous move joins the moves of its iteration, which are applied at once
+	joinsStep := event.Type == structs.AlienMoved && event.Simultaneous && event.Iteration == r.iteration &&
+		r.step != nil && r.step.simultaneous && len(r.consequences) == 0
+	if !joinsStep {
+		if err := r.applyStep(index, &event); err != nil {
+			return err
+		}
+	}
+	if event.Iteration < r.iteration {
+		return &IllegalReplayEventError{index: index, event: event, reason: "events are out of order"}
+	}
+	r.iteration = event.Iteration
+
+	switch event.Type {
+	case structs.AlienSpawned:
+		return r.applySpawn(index, event)
+	case structs.AlienMoved:
+		return r.applyMove(index, event)
+	case structs.SimulationEnded:
+		r.ended = true
+		return nil
+	}
+	return &IllegalReplayEventError{index: index, event: event, reason: "unknown event type"}
+}
+
+// applySpawn places a new alien in the recorded city
+func (r *Replayer) applySpawn(index int, event structs.Event) error {
+	if len(event.AlienIDs) != 1 || len(event.AlienNames) != 1 {
+		return &IllegalReplayEventError{index: index, event: event, reason: "a spawn needs exactly one alien"}
+	}
+	if _, exists := r.aliens[event.AlienIDs[0]]; exists {
+		return &IllegalReplayEventError{index: index, event: event, reason: "the alien has already spawned"}
+	}
+	city, exists := r.world.GetCity(event.City)
+	if !exists {
+		return &IllegalReplayEventError{index: index, event: event, reason: "the city " + event.City + " does not exist"}
+	}
+
+	alien := &structs.Alien{ID: event.AlienIDs[0], Name: event.AlienNames[0]}
+	r.aliens[alien.ID] = alien
+	r.step = &replayStep{moves: []structs.AlienMove{{Alien: alien, To: city}}}
+	return nil
+}
+
+// applyMove moves an alive and free alien along a road of its current city
+func (r *Replayer) applyMove(index int, event structs.Event) error {
+	if len(event.AlienIDs) != 1 {
+		return &IllegalReplayEventError{index: index, event: event, reason: "a move needs exactly one alien"}
+	}
+	alien, exists := r.aliens[event.AlienIDs[0]]
+	if !exists {
+		return &IllegalReplayEventError{index: index, event: event, reason: "the alien has never spawned"}
+	}
+	if alive, _ := r.world.IsAlienAlive(alien); !alive {
+		return &IllegalReplayEventError{index: index, event: event, reason: "the alien is dead"}
+	}
+	if free, _ := r.world.IsAlienFree(alien); !free {
+		return &IllegalReplayEventError{index: index, event: event, reason: "the alien is trapped"}
+	}
+	if alien.Location.Name != event.From {
+		return &IllegalReplayEventError{index: index, event: event, reason: "the alien is in " + alien.Location.Name + ", not in " + event.From}
+	}
+	to := alien.Location.Neighbours[structs.StringToDirection(event.Direction)]
+	if to == nil || to.Name != event.City {
+		return &IllegalReplayEventError{index: index, event: event, reason: event.City + " is not the " + event.Direction + " neighbour of " + event.From}
+	}
+
+	move := structs.AlienMove{Alien: alien, To: to}
+	if !event.Simultaneous {
+		r.step = &replayStep{moves: []structs.AlienMove{move}}
+		return nil
+	}
+	if r.step == nil {
+		r.step = &replayStep{simultaneous: true}
+	}
+	for _, other := range r.step.moves {
+		if other.Alien.ID == alien.ID {
+			return &IllegalReplayEventError{index: index, event: event, reason: "the alien has already moved in this iteration"}
+		}
+	}
+	r.step.moves = append(r.step.moves, move)
+	return nil
+}
+
+// applyStep applies the last recorded step, if any, giving its fights the recorded outcomes, and
+// checks that the replayed world produced the recorded consequences. The next recorded event is
+// reported if the replayed world produced more consequences than were recorded
+func (r *Replayer) applyStep(index int, next *structs.Event) error {
+	step, consequences := r.step, r.consequences
+	r.step, r.consequences = nil, nil
+
+	if step != nil {
+		recordedResolver := &recordedFightResolver{resolver: r.resolver}
+		for _, consequence := range consequences {
+			if consequence.event.Type == structs.FightOccurred {
+				recordedResolver.fights = append(recordedResolver.fights, consequence)
+			}
+		}
+		rng := rand.New(rand.NewSource(0))
+		r.world.SetFightResolver(recordedResolver, rng)
+		err := step.apply(r.world)
+		r.world.SetFightResolver(r.resolver, rng)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, consequence := range consequences {
+		if i == len(r.replayed) {
+			return &ReplayDivergenceError{index: consequence.index, recorded: &consequence.event}
+		}
+		if !reflect.DeepEqual(r.replayed[i], consequence.event) {
+			return &ReplayDivergenceError{index: consequence.index, recorded: &consequence.event, replayed: &r.replayed[i]}
+		}
+	}
+	r.replayed = r.replayed[len(consequences):]
+	if len(r.replayed) != 0 {
+		return &ReplayDivergenceError{index: index, recorded: next, replayed: &r.replayed[0]}
+	}
+	return nil
+}
+
+// apply applies the step to the world
+func (step *replayStep) apply(world *structs.World) error {
+	if step.simultaneous {
+		return world.MoveAliensSimultaneously(step.moves)
+	}
+	_, err := world.AddAlienToCity(step.moves[0].Alien, step.moves[0].To)
+	return err
+}
+
+// ReplayEvents rebuilds the world from the map information and the newline-delimited JSON events
+// read from the reader, stopping once the given iteration is complete (or at the end of the
+// recording if the iteration is nil). The fight resolver is read from the first recorded event
+// naming it, the given resolver, if any, only standing in for a recording which names none and
+// otherwise having to match it. It returns the replayer holding the rebuilt world along with
+// the first problem found in the recording, if any
+func ReplayEvents(mapInfo map[string][]string, resolver structs.FightResolver, events io.Reader, untilIteration *int) (*Replayer, error) {
+	eventScanner := bufio.NewScanner(events)
+	eventScanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	// events are read ahead up to the first one naming the resolver, which decides whether the
+	// aliens meeting before it fight
+	var pending []structs.Event
+	var readErr error
+	for len(pending) == 0 || pending[len(pending)-1].Resolver == "" {
+		event, read, err := scanEvent(eventScanner)
+		if err != nil || !read {
+			readErr = err
+			break
+		}
+		pending = append(pending, event)
+	}
+	if len(pending) != 0 && pending[len(pending)-1].Resolver != "" {
+		var err error
+		if resolver, err = recordedResolver(resolver, pending[len(pending)-1].Resolver); err != nil {
+			return CreateReplayer(mapInfo, nil), err
+		}
+	}
+	replayer := CreateReplayer(mapInfo, resolver)
+
+	for {
+		var event structs.Event
+		if len(pending) != 0 {
+			event, pending = pending[0], pending[1:]
+		} else if readErr != nil {
+			return replayer, readErr
+		} else {
+			var read bool
+			event, read, readErr = scanEvent(eventScanner)
+			if readErr != nil {
+				return replayer, readErr
+			}
+			if !read {
+				break
+			}
+		}
+		// every event of the requested iteration has been applied, except for SimulationEnded
+		// which is stamped with the number of iterations instead
+		if untilIteration != nil && event.Iteration > *untilIteration && event.Type != structs.SimulationEnded {
+			return replayer, replayer.applyStep(replayer.applied, nil)
+		}
+		if err := replayer.Apply(event); err != nil {
+			return replayer, err
+		}
+	}
+
+	return replayer, replayer.applyStep(replayer.applied, nil)
+}
+
+// scanEvent decodes the next event, skipping empty lines, and reports whether there was one left
+func scanEvent(eventScanner *bufio.Scanner) (structs.Event, bool, error) {
+	for eventScanner.Scan() {
+		if len(eventScanner.Bytes()) == 0 {
+			continue
+		}
+		var event structs.Event
+		err := json.Unmarshal(eventScanner.Bytes(), &event)
+		return event, err == nil, err
+	}
+	return structs.Event{}, false, eventScanner.Err()
+}
+
+// recordedResolver parses the resolver named in the recording, checking that the given resolver, if any, is the same one
+func recordedResolver(given structs.FightResolver, recorded string) (structs.FightResolver, error) {
+	if given != nil && given.String() != recorded {
+		return nil, &FightResolverMismatchError{given: given.String(), recorded: recorded}
+	}
+	return structs.ParseFightResolver(recorded)
+}
+
+// recordedFightResolver lets the resolver of the recorded simulation decide whether aliens fight,
+// and gives the fights the outcomes of the recorded fights in turn, so that fights decided at random can be replayed
+type recordedFightResolver struct {
+	resolver structs.FightResolver
+
+	// recorded FightOccurred events which have not been replayed yet
+	fights []recordedEvent
+}
+
+// Resolve returns the outcome of the next recorded fight. The aliens in the fight are checked once the replayed fight is matched to the recorded one
+func (resolver *recordedFightResolver) Resolve(city *structs.City, aliens []*structs.Alien, rng *rand.Rand) (*structs.FightOutcome, error) {
+	outcome, err := resolver.resolver.Resolve(city, aliens, rng)
+	// a fight missing from the recording keeps its outcome and is reported as a divergence
+	if err != nil || outcome == nil || len(resolver.fights) == 0 {
+		return outcome, err
+	}
+	fight := resolver.fights[0]
+	resolver.fights = resolver.fights[1:]
+
+	participants := make(map[int]*structs.Alien)
+	for _, alien := range aliens {
+		participants[alien.ID] = alien
+	}
+
+	outcome = &structs.FightOutcome{CityDestroyed: !fight.event.CitySurvived}
+	for _, survivorID := range fight.event.Survivors {
+		survivor, exists := participants[survivorID]
+		if !exists {
+			return nil, &IllegalReplayEventError{index: fight.index, event: fight.event, reason: "a survivor did not take part in the fight"}
+		}
+		outcome.Survivors = append(outcome.Survivors, survivor)
+	}
+	return outcome, nil
+}
+
+func (resolver *recordedFightResolver) String() string {
+	return resolver.resolver.String()
+}
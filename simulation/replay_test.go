@@ -0,0 +1,142 @@
+package simulation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/AleksandarHr/AlienInvasion/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const replayTestMap = "Foo north=Bar west=Baz south=Qu-ux\n" +
+	"Bar south=Foo west=Bee\n" +
+	"Baz east=Foo\n" +
+	"Qu-ux north=Foo\n" +
+	"Bee east=Bar\n"
+
+// recordRun runs a simulation on the replay test map and returns its events and remaining world
+func recordRun(t *testing.T, mapFileName string, seed int64, movement MovementMode, resolver structs.FightResolver) (string, string) {
+	var events strings.Builder
+	simulation, err := CreateSimulation(Config{AliensCount: 4, MapFileName: mapFileName, MaxIterations: 20, Seed: seed, EventWriter: &events, Movement: movement, FightResolver: resolver})
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	simulation.InitializeSimulation()
+	result := simulation.Run(context.Background())
+
+	var remainingWorld strings.Builder
+	result.World.WriteMap(&remainingWorld)
+	return events.String(), remainingWorld.String()
+}
+
+func TestReplayEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	mapFileName := writeMapFile(t, replayTestMap)
+	mapInfo, _ := utils.ParseInputFile(mapFileName)
+	// fights decided at random are replayed with their recorded outcome, aliens below the threshold
+	// of the resolver share cities, and simultaneous moves are replayed together
+	for _, movement := range []MovementMode{Sequential, Simultaneous} {
+		for _, resolver := range []structs.FightResolver{
+			nil,
+			structs.ProbabilisticResolver{SurvivalProbability: 0.5},
+			structs.CitySurvivesResolver{SurvivalProbability: 0.5},
+			structs.WinnerTakesCityResolver{},
+			structs.ThresholdResolver{Threshold: 3},
+		} {
+			for seed := int64(0); seed < 10; seed++ {
+				events, remainingWorld := recordRun(t, mapFileName, seed, movement, resolver)
+
+				replayer, err := ReplayEvents(mapInfo, resolver, strings.NewReader(events), nil)
+				assert.Nil(err, "A recorded %s run should replay without problems.", movement)
+				assert.True(replayer.Ended(), "The whole recording should have been replayed.")
+
+				var replayedWorld strings.Builder
+				replayer.World().WriteMap(&replayedWorld)
+				assert.Equal(remainingWorld, replayedWorld.String(), "The replayed world should match the simulated one.")
+			}
+		}
+	}
+}
+
+func TestReplayEventsRecordedResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	mapFileName := writeMapFile(t, replayTestMap)
+	mapInfo, _ := utils.ParseInputFile(mapFileName)
+	for seed := int64(0); seed < 10; seed++ {
+		events, remainingWorld := recordRun(t, mapFileName, seed, Sequential, structs.ThresholdResolver{Threshold: 3})
+
+		// aliens below the threshold share cities before the resolver is first named
+		replayer, err := ReplayEvents(mapInfo, nil, strings.NewReader(events), nil)
+		assert.Nil(err, "The resolver should be read from the recording.")
+		var replayedWorld strings.Builder
+		replayer.World().WriteMap(&replayedWorld)
+		assert.Equal(remainingWorld, replayedWorld.String(), "The replayed world should match the simulated one.")
+
+		_, err = ReplayEvents(mapInfo, structs.MutualDestructionResolver{}, strings.NewReader(events), nil)
+		assert.IsType(&FightResolverMismatchError{}, err, "A resolver contradicting the recording should be rejected.")
+		assert.Equal("The fight resolver mutual-destruction does not match the resolver threshold:3 the events were recorded with.\n", err.Error())
+	}
+
+	// a recording naming no resolver is replayed with the given one
+	events := `{"type":"AlienSpawned","iteration":-1,"alienIds":[0],"alienNames":["a_0"],"city":"Foo"}
+{"type":"AlienTrapped","iteration":-1,"alienIds":[0],"alienNames":["a_0"],"city":"Foo"}
+{"type":"AlienSpawned","iteration":-1,"alienIds":[1],"alienNames":["b_1"],"city":"Foo"}
+{"type":"AlienTrapped","iteration":-1,"alienIds":[1],"alienNames":["b_1"],"city":"Foo"}
+`
+	_, err := ReplayEvents(map[string][]string{"Foo": {}}, structs.ThresholdResolver{Threshold: 3}, strings.NewReader(events), nil)
+	assert.Nil(err, "The given resolver should stand in for the recording.")
+}
+
+func TestReplayEventsUntilIteration(t *testing.T) {
+	assert := assert.New(t)
+
+	mapInfo := map[string][]string{"Foo": {"north=Bar"}, "Bar": {"south=Foo"}}
+	events := `{"type":"AlienSpawned","iteration":-1,"alienIds":[0],"alienNames":["a_0"],"city":"Foo"}
+{"type":"AlienMoved","iteration":0,"alienIds":[0],"alienNames":["a_0"],"city":"Bar","from":"Foo","direction":"north"}
+{"type":"AlienMoved","iteration":1,"alienIds":[0],"alienNames":["a_0"],"city":"Foo","from":"Bar","direction":"south"}
+`
+	for iteration, expectedCity := range map[int]string{-1: "Foo", 0: "Bar", 1: "Foo"} {
+		until := iteration
+		replayer, err := ReplayEvents(mapInfo, nil, strings.NewReader(events), &until)
+		assert.Nil(err, "The recording should replay without problems.")
+		aliens, _ := replayer.World().GetAllAliens()
+		assert.Equal(expectedCity, aliens[0].Location.Name, "The alien should be where it was after the iteration.")
+	}
+}
+
+func TestReplayEventsIllegalMove(t *testing.T) {
+	assert := assert.New(t)
+
+	mapInfo := map[string][]string{"Foo": {"north=Bar"}, "Bar": {"south=Foo"}, "Baz": {}}
+	events := `{"type":"AlienSpawned","iteration":-1,"alienIds":[0],"alienNames":["a_0"],"city":"Foo"}
+{"type":"AlienMoved","iteration":0,"alienIds":[0],"alienNames":["a_0"],"city":"Baz","from":"Foo","direction":"north"}
+`
+	_, err := ReplayEvents(mapInfo, nil, strings.NewReader(events), nil)
+	assert.IsType(&IllegalReplayEventError{}, err, "Moving to a city which is not a neighbour should be illegal.")
+
+	events = `{"type":"AlienSpawned","iteration":-1,"alienIds":[0],"alienNames":["a_0"],"city":"Baz"}
+{"type":"AlienTrapped","iteration":-1,"alienIds":[0],"alienNames":["a_0"],"city":"Baz"}
+{"type":"AlienMoved","iteration":0,"alienIds":[0],"alienNames":["a_0"],"city":"Foo","from":"Baz","direction":"south"}
+`
+	_, err = ReplayEvents(mapInfo, nil, strings.NewReader(events), nil)
+	assert.IsType(&IllegalReplayEventError{}, err, "Moving a trapped alien should be illegal.")
+}
+
+func TestReplayEventsDivergence(t *testing.T) {
+	assert := assert.New(t)
+
+	mapInfo := map[string][]string{"Foo": {}}
+	events := `{"type":"AlienSpawned","iteration":-1,"alienIds":[0],"alienNames":["a_0"],"city":"Foo"}
+{"type":"AlienTrapped","iteration":-1,"alienIds":[0],"alienNames":["a_0"],"city":"Foo"}
+{"type":"AlienSpawned","iteration":-1,"alienIds":[1],"alienNames":["b_1"],"city":"Foo"}
+{"type":"SimulationEnded","iteration":0,"stopReason":"All Aliens Dead"}
+`
+	replayer, err := ReplayEvents(mapInfo, nil, strings.NewReader(events), nil)
+	assert.IsType(&ReplayDivergenceError{}, err, "The missing fight should be reported.")
+	assert.Equal("Replay diverged at event #3 in iteration 0: recorded SimulationEnded, replayed FightOccurred of aliens [0 1] in Foo.\n", err.Error())
+	assert.Equal(-1, replayer.Iteration(), "The replay should stop at the divergence.")
+}
@@ -0,0 +1,59 @@
+package simulation
+
+import "github.com/AleksandarHr/AlienInvasion/structs"
+
+// SimulationResult holds the outcome of a simulation run
+type SimulationResult struct {
+	// reason for which the simulation stopped
+	StopReason StopReason
+
+	// number of fully simulated iterations
+	Iterations int
+
+	// names of the destroyed cities in the order of their destruction
+	DestroyedCities []string
+
+	// fights in the order they took place
+	Fights []Fight
+
+	// name of the resolver which decided the outcome of the fights
+	FightResolver string
+
+	// aliens alive at the end of the simulation, ordered by ID
+	SurvivingAliens []AlienInfo
+
+	// errors encountered during the simulation
+	Errors []error
+
+	// what remains of the world
+	World *structs.World
+}
+
+// Fight holds information about a fight between aliens
+type Fight struct {
+	// iteration during which the fight took place, -1 while spawning aliens
+	Iteration int
+
+	// name of the city the fight took place in, or of one end of the road it took place on
+	City string
+
+	// name of the other end of the road the fight took place on, empty for fights in a city
+	From string `json:",omitempty"`
+
+	// IDs of the aliens who took part in the fight
+	AlienIDs []int
+
+	// IDs of the aliens who survived the fight
+	Survivors []int `json:",omitempty"`
+
+	// whether the city, or the road, was left standing
+	CitySurvived bool `json:",omitempty"`
+}
+
+// AlienInfo holds information about an alien at the end of the simulation
+type AlienInfo struct {
+	ID       int
+	Name     string
+	Location string
+	Trapped  bool
+}
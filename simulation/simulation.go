@@ -1,19 +1,102 @@
 package simulation
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
-	"os"
+	"io"
+	"math/rand"
 
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
 	"github.com/AleksandarHr/AlienInvasion/structs"
 	"github.com/AleksandarHr/AlienInvasion/utils"
 	"github.com/phuslu/log"
 )
 
+// Config holds the parameters of a simulation
+type Config struct {
+	// number of aliens to spawn in the world before the first iteration
+	AliensCount int
+
+	// waves of aliens spawned during the iterations, no waves if not set
+	Waves *WaveSchedule
+
+	// strategy choosing the cities the aliens spawn in, uniformly random if not set
+	SpawnStrategy structs.SpawnStrategy
+
+	// name of the file containing the map of the world
+	MapFileName string
+
+	// format of the map file (text, json or yaml), matching the extension of the file name if empty
+	MapFormat string
+
+	// already loaded map of the world, used instead of MapFileName when set
+	MapInfo map[string][]string
+
+	// maximum number of iterations to simulate
+	MaxIterations int
+
+	// seed of the random source; the same seed, map and aliens count reproduce the same run
+	Seed int64
+
+	// writer receiving every event of the simulation as newline-delimited JSON, if set
+	EventWriter io.Writer
+
+	// number of iterations between automatic checkpoints, no automatic checkpoints if 0
+	CheckpointEvery int
+
+	// file the checkpoints are written to, no checkpoints are written if empty
+	CheckpointFileName string
+
+	// file the world is rendered to as a DOT graph once the simulation ends, not rendered if empty
+	DOTFileName string
+
+	// iterations after which the world is also rendered (-1 for right after spawning), each to
+	// the file named by DOTFileNameAt
+	DOTIterations []int
+
+	// options of the DOT renderings
+	DOTOptions structs.DOTOptions
+
+	// strategy moving the aliens, uniformly random if not set
+	MovementStrategy structs.MovementStrategy
+
+	// alien IDs mapped to the strategy moving that alien instead of MovementStrategy
+	AlienStrategies map[int]structs.MovementStrategy
+
+	// resolver deciding the outcome of fights, mutual destruction if not set
+	FightResolver structs.FightResolver
+
+	// whether the aliens move one at a time or all at once, sequentially if not set
+	Movement MovementMode
+
+	// disables logging, for simulations run in bulk
+	Silent bool
+}
+
 type Simulation struct {
 	initialAliensCount int
 
+	// strategy choosing the cities the aliens spawn in
+	spawnStrategy structs.SpawnStrategy
+
+	// waves of aliens spawned during the iterations, nil if there are none
+	waves *WaveSchedule
+
+	// number of aliens spawned so far, which is also the ID of the next alien
+	spawnedAliens int
+
 	maxIterations int
 
+	// seed of the random source, logged so that the run can be reproduced
+	seed int64
+
+	// random source driving every random choice in the simulation
+	rng *rand.Rand
+
+	// source of rng, kept to save and restore its state
+	source *utils.RandomSource
+
 	mapInfo map[string][]string
 
 	world *structs.World
@@ -23,158 +106,607 @@ type Simulation struct {
 	debugLogger log.Logger
 
 	stage structs.SimulationStage
+
+	// number of fully simulated iterations
+	iteration int
+
+	// outcome of the simulation, filled in as the simulation progresses
+	result *SimulationResult
+
+	// encodes events into the event writer, if one was provided
+	eventEncoder *json.Encoder
+
+	// number of iterations between automatic checkpoints
+	checkpointEvery int
+
+	// file the checkpoints are written to
+	checkpointFileName string
+
+	// file the world is rendered to once the simulation ends, and the iterations after which it is also rendered
+	dotFileName   string
+	dotIterations map[int]bool
+	dotOptions    structs.DOTOptions
+
+	// whether the iterations have started, and whether the simulation has finished
+	started bool
+	done    bool
+
+	// whether an iteration is in progress, and the aliens still to move in it when moving sequentially
+	inIteration   bool
+	pendingAliens []*structs.Alien
+
+	// notified of everything happening in the simulation
+	observers []Observer
+
+	// strategy moving the aliens without a strategy of their own
+	movementStrategy structs.MovementStrategy
+
+	// alien IDs mapped to the strategy moving that alien
+	alienStrategies map[int]structs.MovementStrategy
+
+	// whether the aliens move one at a time or all at once
+	movement MovementMode
 }
 
-func CreateSimulation(aliens int, mapFileName string, maxIterations int) (*Simulation, error) {
-	defaultLog, debugLog := utils.InitializeLogger()
-	worldMap, err := utils.ParseInputFile(mapFileName)
-	if err != nil {
-		return nil, err
+func CreateSimulation(config Config) (*Simulation, error) {
+	defaultLog, debugLog := initializeLoggers(config)
+	worldMap := config.MapInfo
+	if worldMap == nil {
+		var err error
+		worldMap, err = mapfile.LoadMapInfo(config.MapFileName, config.MapFormat)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	world := structs.CreateWorld()
+	source := utils.CreateRandomSource(config.Seed)
 
-	return &Simulation{
-		initialAliensCount: aliens,
-		maxIterations:      maxIterations,
+	spawnStrategy := config.SpawnStrategy
+	if spawnStrategy == nil {
+		spawnStrategy = structs.UniformSpawnStrategy{}
+	}
+
+	simulation := &Simulation{
+		initialAliensCount: config.AliensCount,
+		spawnStrategy:      spawnStrategy,
+		waves:              config.Waves,
+		maxIterations:      config.MaxIterations,
+		seed:               config.Seed,
+		rng:                rand.New(source),
+		source:             source,
 		mapInfo:            worldMap,
 		world:              world,
 		defaultLogger:      defaultLog,
 		debugLogger:        debugLog,
 		stage:              structs.SimulationStart,
-	}, nil
+		result:             &SimulationResult{StopReason: NotStopped},
+		movement:           config.Movement,
+	}
+	simulation.attachOutputs(config)
+	simulation.setStrategies(config.MovementStrategy, config.AlienStrategies)
+	simulation.setFightResolver(config.FightResolver)
+
+	return simulation, nil
+}
+
+// initializeLoggers returns the loggers requested by the config
+func initializeLoggers(config Config) (log.Logger, log.Logger) {
+	if config.Silent {
+		return utils.InitializeSilentLogger()
+	}
+	return utils.InitializeLogger()
+}
+
+// attachOutputs sets up the event stream, the checkpoints and the DOT renderings requested by the config
+func (s *Simulation) attachOutputs(config Config) {
+	if config.EventWriter != nil {
+		s.eventEncoder = json.NewEncoder(config.EventWriter)
+	}
+	s.world.SetEventRecorder(structs.EventRecorderFunc(s.recordEvent))
+
+	s.checkpointEvery = config.CheckpointEvery
+	s.checkpointFileName = config.CheckpointFileName
+
+	s.dotFileName = config.DOTFileName
+	s.dotIterations = make(map[int]bool)
+	for _, iteration := range config.DOTIterations {
+		s.dotIterations[iteration] = true
+	}
+	s.dotOptions = config.DOTOptions
+}
+
+// setStrategies sets the strategies moving the aliens, defaulting to uniformly random moves
+func (s *Simulation) setStrategies(movementStrategy structs.MovementStrategy, alienStrategies map[int]structs.MovementStrategy) {
+	if movementStrategy == nil {
+		movementStrategy = structs.UniformStrategy{}
+	}
+	s.movementStrategy = movementStrategy
+	s.alienStrategies = make(map[int]structs.MovementStrategy)
+	for alienID, strategy := range alienStrategies {
+		s.alienStrategies[alienID] = strategy
+	}
+}
+
+// setFightResolver sets the resolver deciding the outcome of fights, defaulting to mutual destruction
+func (s *Simulation) setFightResolver(resolver structs.FightResolver) {
+	if resolver == nil {
+		resolver = structs.MutualDestructionResolver{}
+	}
+	s.world.SetFightResolver(resolver, s.rng)
 }
 
-func (s *Simulation) InitializeSimulation() {
+// strategyFor returns the strategy moving the given alien
+func (s *Simulation) strategyFor(alien *structs.Alien) structs.MovementStrategy {
+	if strategy, exists := s.alienStrategies[alien.ID]; exists {
+		return strategy
+	}
+	return s.movementStrategy
+}
+
+// InitializeSimulation creates the world and spawns the aliens in it.
+// If there are no cities left to spawn aliens in, the simulation is stopped
+func (s *Simulation) InitializeSimulation() error {
 	s.stage = structs.InitializingWorld
+	s.defaultLogger.Info().Msgf("Simulating with seed %d, %s moves, the %s movement strategy and the %s fight resolver.", s.seed, s.movement, s.movementStrategy, s.world.FightResolver())
+	s.debugLogger.Info().Msgf("Simulating with seed %d, %s moves, the %s movement strategy and the %s fight resolver.", s.seed, s.movement, s.movementStrategy, s.world.FightResolver())
 
 	s.world.InitializeWorld(s.mapInfo)
 
 	s.stage = structs.SpawningAliens
-	// Spawn aliens
-	for i := 0; i < s.initialAliensCount; i++ {
-		alien := structs.CreateAlien(i)
+	aliensCount := s.initialAliensCount
+	if s.waves != nil {
+		aliensCount = s.waves.Total(s.initialAliensCount, s.maxIterations)
+	}
+	if err := s.spawnStrategy.Validate(s.world, aliensCount); err != nil {
+		s.defaultLogger.Err(err).Msgf("Cannot spawn %d aliens with the %s spawn strategy. Exitting simulation.", aliensCount, s.spawnStrategy)
+		s.debugLogger.Err(err).Msgf("Cannot spawn %d aliens with the %s spawn strategy. Exitting simulation.", aliensCount, s.spawnStrategy)
+		return s.fail(err)
+	}
 
-		allCities, err := s.world.GetAllCities()
+	return s.spawnAliens(s.initialAliensCount)
+}
+
+// spawnAliens spawns the given number of new aliens, numbered after the aliens spawned so far.
+// If there are no cities left to spawn aliens in, the simulation is stopped
+func (s *Simulation) spawnAliens(count int) error {
+	for i := 0; i < count; i++ {
+		alien := structs.CreateAlien(s.spawnedAliens, s.rng)
+		s.spawnedAliens++
+
+		originCity, err := s.spawnStrategy.SpawnCity(s.world, alien, s.rng)
 		if err != nil {
-			s.defaultLogger.Err(errors.New("Error retrieving available cities. Exitting simulation."))
-			s.debugLogger.Err(errors.New("Error retrieving available cities. Exitting simulation."))
-			os.Exit(1)
+			s.defaultLogger.Err(err).Msg("Error choosing a spawn city. Exitting simulation.")
+			s.debugLogger.Err(err).Msg("Error choosing a spawn city. Exitting simulation.")
+			return s.fail(err)
 		}
-		if len(allCities) == 0 {
+		if originCity == nil {
 			s.defaultLogger.Info().Msg("No cities left in the world. Exitting simulation.")
 			s.debugLogger.Info().Msg("No cities left in the world. Exitting simulation.")
-			os.Exit(0)
+			s.stop(NoCitiesLeft)
+			return nil
 		}
 
-		randCityIdx, err := utils.GenerateRandomNumber(len(allCities))
-		if err != nil {
-			s.defaultLogger.Err(errors.New("Error choosing a random spawn city. Exitting simulation."))
-			s.debugLogger.Info().Msg("No cities left in the world. Exitting simulation.")
-			os.Exit(1)
-		}
-
-		originCity := allCities[randCityIdx]
+		s.recordEvent(structs.Event{
+			Type:       structs.AlienSpawned,
+			AlienIDs:   []int{alien.ID},
+			AlienNames: []string{alien.Name},
+			City:       originCity.Name,
+		})
 		// NOTE: It is possible to spanw an alien at a city where there already is an alien!
-		added, err := s.world.AddAlienToCity(alien, originCity, s.stage)
+		added, err := s.world.AddAlienToCity(alien, originCity)
 		if err != nil {
 			s.defaultLogger.Err(err).Msgf("Cannot add an alien to an invalid city %v.", originCity)
 			s.debugLogger.Err(err).Msgf("Cannot add an alien to an invalid city %v.", originCity)
+			s.result.Errors = append(s.result.Errors, err)
 			continue
 		}
 		if added {
 			s.defaultLogger.Info().Msgf("Alien %d spawned in %s.", alien.ID, originCity.Name)
 			s.debugLogger.Info().Msgf("Alien %d spawned in %s.", alien.ID, originCity.Name)
+		} else if _, standing := s.world.GetCity(originCity.Name); standing {
+			s.defaultLogger.Info().Msgf("Alien %d tried to spawn in %s where an alien already exists and was killed.", alien.ID, originCity.Name)
+			s.debugLogger.Info().Msgf("Alien %d tried to spawn in %s where an alien already exists and was killed.", alien.ID, originCity.Name)
 		} else {
 			s.defaultLogger.Info().Msgf("Alien %d tried to spawn in %s where an alien already exists. %s was destroyed.", alien.ID, originCity.Name, originCity.Name)
 			s.debugLogger.Info().Msgf("Alien %d tried to spawn in %s where an alien already exists. %s was destroyed.", alien.ID, originCity.Name, originCity.Name)
 		}
-
-		s.world.LogWorldState(s.debugLogger)
 	}
+	s.world.LogWorldState(s.debugLogger)
+	return nil
 }
 
-func (s *Simulation) Run() {
-	// Simulate iterations
-	iteration := 0
-	s.stage = structs.MovingAliens
-	for {
-		// If the simulation has ran for maxIterations number of iterations, exit
-		if iteration == s.maxIterations {
-			s.defaultLogger.Info().Msg("Reached maximum number of iterations. Exitting simulation.")
-			s.debugLogger.Info().Msg("Reached maximum number of iterations. Exitting simulation.")
-			os.Exit(0)
+// wavesPending checks whether more aliens will spawn in a later iteration
+func (s *Simulation) wavesPending() bool {
+	return s.waves != nil && !s.world.AllCitiesDestroyed() && s.waves.PendingAfter(s.iteration, s.maxIterations, s.spawnedAliens)
+}
+
+// Run moves the aliens around the world until one of the stopping conditions is met or the context
+// is done, and returns the outcome of the simulation. The context is checked between alien moves,
+// so a cancelled simulation stops in the middle of its iteration with a partial result
+func (s *Simulation) Run(ctx context.Context) *SimulationResult {
+	for !s.Done() {
+		if err := ctx.Err(); err != nil {
+			s.cancel(err)
+			break
 		}
+		s.StepAlien()
+	}
+	return s.Result()
+}
+
+// Done checks whether the simulation has stopped
+func (s *Simulation) Done() bool {
+	return s.done
+}
+
+// Result returns the outcome of the simulation, complete once the simulation is done
+func (s *Simulation) Result() *SimulationResult {
+	return s.result
+}
 
-		// If all the aliens have died, exit
-		if s.world.AllAliensDead() {
-			s.defaultLogger.Info().Msg("All aliens have died. Exitting simulation.")
-			s.debugLogger.Info().Msg("All aliens have died. Exitting simulation.")
-			os.Exit(0)
+// World returns the simulated world
+func (s *Simulation) World() *structs.World {
+	return s.world
+}
+
+// Iteration returns the number of fully simulated iterations
+func (s *Simulation) Iteration() int {
+	return s.iteration
+}
+
+// Step simulates the rest of the current iteration, or the next iteration if none is in progress.
+// The stopping conditions are checked at the start of every iteration, so the step after the last
+// iteration only stops the simulation
+func (s *Simulation) Step() {
+	if !s.beginIteration() {
+		return
+	}
+	if s.movement == Simultaneous {
+		s.moveAliensSimultaneously()
+	} else {
+		for len(s.pendingAliens) > 0 {
+			s.moveNextAlien()
 		}
+	}
+	s.endIteration()
+}
 
-		// If all remaining aliens are trapped (e.g. cannot move), exit
-		if s.world.AllAliensTrapped() {
-			s.defaultLogger.Info().Msg("All aliens are trapped in isolated cities. Exitting simulation.")
-			s.debugLogger.Info().Msg("All aliens are trapped in isolated cities. Exitting simulation.")
-			os.Exit(0)
+// StepAlien moves the next alien of the current iteration, starting the next iteration if none is
+// in progress, and ends the iteration once every alien has had its move. Aliens which died or got
+// trapped earlier in the iteration are skipped. In simultaneous mode the aliens move all at once,
+// so the whole iteration is simulated
+func (s *Simulation) StepAlien() {
+	if !s.beginIteration() {
+		return
+	}
+	if s.movement == Simultaneous {
+		s.moveAliensSimultaneously()
+		s.endIteration()
+		return
+	}
+	for len(s.pendingAliens) > 0 && !s.moveNextAlien() {
+	}
+	if len(s.pendingAliens) == 0 {
+		s.endIteration()
+	}
+}
+
+// beginIteration starts the next iteration unless one is already in progress: it stops the
+// simulation if one of the stopping conditions is met, and otherwise spawns the wave of
+// reinforcements due and decides the order the aliens move in. It returns false once the
+// simulation is done
+func (s *Simulation) beginIteration() bool {
+	if s.done {
+		return false
+	}
+	if !s.started {
+		s.started = true
+		s.stage = structs.MovingAliens
+		if s.iteration == 0 {
+			s.renderIteration(-1)
 		}
+		// The simulation might have already been stopped while initializing
+		if s.result.StopReason != NotStopped {
+			s.finish()
+			return false
+		}
+	}
+	if s.inIteration {
+		return true
+	}
 
-		// Otherwise, continue the simulation
-		currentFreeAliens, _ := s.world.GetFreeAliens()
-		for _, alien := range currentFreeAliens {
+	// If the simulation has ran for maxIterations number of iterations, exit
+	if s.iteration == s.maxIterations {
+		s.defaultLogger.Info().Msg("Reached maximum number of iterations. Exitting simulation.")
+		s.debugLogger.Info().Msg("Reached maximum number of iterations. Exitting simulation.")
+		s.stop(MaxIterationsReached)
+		s.finish()
+		return false
+	}
 
-			// if the alien died while executing current iterations, continue to next alien
-			alive, err := s.world.IsAlienAlive(alien)
-			if err != nil {
-				// invalid alien, simply move to the next one
-				continue
-			}
-			if !alive {
-				s.debugLogger.Debug().Msgf("Alien %d died during current iteration.", alien.ID)
-				continue
+	// Spawn the wave of reinforcements due at the start of the iteration, if any
+	if s.waves != nil {
+		if count := s.waves.AliensAt(s.iteration, s.spawnedAliens); count > 0 {
+			s.defaultLogger.Info().Msgf("A wave of %d aliens arrives in iteration %d.", count, s.iteration)
+			s.debugLogger.Info().Msgf("A wave of %d aliens arrives in iteration %d.", count, s.iteration)
+			s.spawnAliens(count)
+			if s.result.StopReason != NotStopped {
+				s.finish()
+				return false
 			}
+		}
+	}
 
-			// if the alien got trapped while executing current iterations, continue to next alien
-			free, err := s.world.IsAlienFree(alien)
-			if err != nil {
-				// invalid alien, simply move to the next one
-				continue
-			}
-			if !free {
-				s.debugLogger.Debug().Msgf("Alien %d got trapped in %s during current iteration. No valid move.", alien.ID, alien.Location.Name)
-				continue
-			}
+	// The remaining stopping conditions wait for the pending waves
+	wavesPending := s.wavesPending()
 
-			// If alien is free, move to a random neighbouring city
-			newAlienCity, err := alien.PickRandomNeighbourCity()
-			if err != nil {
-				// unable to pick a random neighbour city
-				s.debugLogger.Debug().Msgf("Error trying to move alien %d to a random neighbour: %v", err)
-				continue
-			}
-			if newAlienCity == nil {
-				// alien is trapped
-				s.debugLogger.Debug().Msgf("Alien %d is trapped in %s.", alien.ID, alien.Location.Name)
-				continue
-			}
+	// If all the aliens have died, exit
+	if s.world.AllAliensDead() && !wavesPending {
+		s.defaultLogger.Info().Msg("All aliens have died. Exitting simulation.")
+		s.debugLogger.Info().Msg("All aliens have died. Exitting simulation.")
+		s.stop(AllAliensDead)
+		s.finish()
+		return false
+	}
 
-			// move alien to neighbour and update world information
-			added, err := s.world.AddAlienToCity(alien, newAlienCity, s.stage)
-			if err != nil {
-				// error trying to move alien to city, simply continue with next alien
-				s.debugLogger.Debug().Msgf("Unable to move alien %d to a random neighbour: %v", err)
-				continue
-			}
-			if added {
-				s.defaultLogger.Info().Msgf("Alien %d moved to %s.", alien.ID, newAlienCity.Name)
-				s.debugLogger.Info().Msgf("Alien %d moved to %s.", alien.ID, newAlienCity.Name)
-			} else {
-				s.defaultLogger.Info().Msgf("Alien %d tried to move where an alien already exists. %s was destroyed.", alien.ID, newAlienCity.Name)
-				s.debugLogger.Info().Msgf("Alien %d tried to move where an alien already exists. %s was destroyed.", alien.ID, newAlienCity.Name)
-			}
+	// If all remaining aliens are trapped (e.g. cannot move), exit
+	if s.world.AllAliensTrapped() && !wavesPending {
+		s.defaultLogger.Info().Msg("All aliens are trapped in isolated cities. Exitting simulation.")
+		s.debugLogger.Info().Msg("All aliens are trapped in isolated cities. Exitting simulation.")
+		s.stop(AllAliensTrapped)
+		s.finish()
+		return false
+	}
+
+	// Otherwise, continue the simulation; sequential moves are made by the free aliens in a random order
+	s.inIteration = true
+	if s.movement == Sequential {
+		s.pendingAliens, _ = s.world.GetFreeAliens()
+		s.rng.Shuffle(len(s.pendingAliens), func(i, j int) {
+			s.pendingAliens[i], s.pendingAliens[j] = s.pendingAliens[j], s.pendingAliens[i]
+		})
+	}
+	return true
+}
+
+// endIteration completes the current iteration, saving a checkpoint if one is due
+func (s *Simulation) endIteration() {
+	s.inIteration = false
+	s.pendingAliens = nil
+	s.iteration++
+	for _, observer := range s.observers {
+		observer.OnIterationEnd(s.iteration-1, s.world)
+	}
+	s.renderIteration(s.iteration - 1)
+	if s.checkpointEvery > 0 && s.iteration%s.checkpointEvery == 0 {
+		s.saveCheckpoint()
+	}
+}
+
+// moveNextAlien lets the next alien of the current iteration make its move, returning false if it
+// could not move because it died or got trapped earlier in the iteration
+func (s *Simulation) moveNextAlien() bool {
+	alien := s.pendingAliens[0]
+	s.pendingAliens = s.pendingAliens[1:]
+
+	// if the alien died while executing current iterations, it cannot move
+	alive, err := s.world.IsAlienAlive(alien)
+	if err != nil {
+		// invalid alien, it cannot move
+		s.result.Errors = append(s.result.Errors, err)
+		return false
+	}
+	if !alive {
+		s.debugLogger.Debug().Msgf("Alien %d died during current iteration.", alien.ID)
+		return false
+	}
+
+	// if the alien got trapped while executing current iterations, it cannot move
+	free, err := s.world.IsAlienFree(alien)
+	if err != nil {
+		// invalid alien, it cannot move
+		s.result.Errors = append(s.result.Errors, err)
+		return false
+	}
+	if !free {
+		s.debugLogger.Debug().Msgf("Alien %d got trapped in %s during current iteration. No valid move.", alien.ID, alien.Location.Name)
+		return false
+	}
+
+	// If alien is free, let its strategy pick a neighbouring city
+	newAlienCity, err := s.strategyFor(alien).NextCity(s.world, alien, s.rng)
+	if err != nil {
+		// unable to pick a neighbour city
+		s.debugLogger.Debug().Msgf("Error trying to move alien %d to a neighbour: %v", alien.ID, err)
+		s.result.Errors = append(s.result.Errors, err)
+		return true
+	}
+	if newAlienCity == nil {
+		// alien stays where it is for this iteration
+		s.debugLogger.Debug().Msgf("Alien %d stays in %s.", alien.ID, alien.Location.Name)
+		return true
+	}
+
+	// move alien to neighbour and update world information
+	oldAlienCity := alien.Location
+	s.recordEvent(structs.Event{
+		Type:       structs.AlienMoved,
+		AlienIDs:   []int{alien.ID},
+		AlienNames: []string{alien.Name},
+		City:       newAlienCity.Name,
+		From:       oldAlienCity.Name,
+		Direction:  oldAlienCity.DirectionTo(newAlienCity).MapKeyword(),
+	})
+	added, err := s.world.AddAlienToCity(alien, newAlienCity)
+	if err != nil {
+		// error trying to move alien to city, the alien has had its move
+		s.debugLogger.Debug().Msgf("Unable to move alien %d to a random neighbour: %v", alien.ID, err)
+		s.result.Errors = append(s.result.Errors, err)
+		return true
+	}
+	if added {
+		s.defaultLogger.Info().Msgf("Alien %d moved to %s.", alien.ID, newAlienCity.Name)
+		s.debugLogger.Info().Msgf("Alien %d moved to %s.", alien.ID, newAlienCity.Name)
+	} else if _, standing := s.world.GetCity(newAlienCity.Name); standing {
+		s.defaultLogger.Info().Msgf("Alien %d tried to move to %s where an alien already exists and was killed.", alien.ID, newAlienCity.Name)
+		s.debugLogger.Info().Msgf("Alien %d tried to move to %s where an alien already exists and was killed.", alien.ID, newAlienCity.Name)
+	} else {
+		s.defaultLogger.Info().Msgf("Alien %d tried to move where an alien already exists. %s was destroyed.", alien.ID, newAlienCity.Name)
+		s.debugLogger.Info().Msgf("Alien %d tried to move where an alien already exists. %s was destroyed.", alien.ID, newAlienCity.Name)
+	}
+	return true
+}
+
+// moveAliensSimultaneously lets every free alien pick its destination and then moves them all at once.
+// Aliens swapping cities along the same road meet on the road, and aliens arriving in the same city
+// meet there along with the aliens staying in it
+func (s *Simulation) moveAliensSimultaneously() {
+	currentFreeAliens, _ := s.world.GetFreeAliens()
+	moves := []structs.AlienMove{}
+	for _, alien := range currentFreeAliens {
+		newAlienCity, err := s.strategyFor(alien).NextCity(s.world, alien, s.rng)
+		if err != nil {
+			// unable to pick a neighbour city
+			s.debugLogger.Debug().Msgf("Error trying to move alien %d to a neighbour: %v", alien.ID, err)
+			s.result.Errors = append(s.result.Errors, err)
+			continue
+		}
+		if newAlienCity == nil {
+			// alien stays where it is for this iteration
+			s.debugLogger.Debug().Msgf("Alien %d stays in %s.", alien.ID, alien.Location.Name)
+			continue
 		}
+		moves = append(moves, structs.AlienMove{Alien: alien, To: newAlienCity})
+	}
+
+	for _, move := range moves {
+		s.recordEvent(structs.Event{
+			Type:         structs.AlienMoved,
+			AlienIDs:     []int{move.Alien.ID},
+			AlienNames:   []string{move.Alien.Name},
+			City:         move.To.Name,
+			From:         move.Alien.Location.Name,
+			Direction:    move.Alien.Location.DirectionTo(move.To).MapKeyword(),
+			Simultaneous: true,
+		})
+	}
+	if err := s.world.MoveAliensSimultaneously(moves); err != nil {
+		s.debugLogger.Debug().Msgf("Unable to move the aliens: %v", err)
+		s.result.Errors = append(s.result.Errors, err)
+		return
+	}
+
+	for _, move := range moves {
+		if alive, _ := s.world.IsAlienAlive(move.Alien); alive {
+			s.defaultLogger.Info().Msgf("Alien %d moved to %s.", move.Alien.ID, move.To.Name)
+			s.debugLogger.Info().Msgf("Alien %d moved to %s.", move.Alien.ID, move.To.Name)
+		} else {
+			s.defaultLogger.Info().Msgf("Alien %d was killed on its way to %s.", move.Alien.ID, move.To.Name)
+			s.debugLogger.Info().Msgf("Alien %d was killed on its way to %s.", move.Alien.ID, move.To.Name)
+		}
+	}
+}
+
+// cancel stops the simulation because its context is done, saving a checkpoint if a checkpoint
+// file was configured. The checkpoint holds the aliens still to move, so an iteration cut short
+// is completed when the simulation is resumed
+func (s *Simulation) cancel(err error) {
+	s.defaultLogger.Info().Msgf("Simulation cancelled after %d iterations (%v). Exitting simulation.", s.iteration, err)
+	s.debugLogger.Info().Msgf("Simulation cancelled after %d iterations (%v). Exitting simulation.", s.iteration, err)
+	s.saveCheckpoint()
+	s.stop(Cancelled)
+	s.finish()
+}
 
-		iteration++
+// stop records the reason for which the simulation stopped
+func (s *Simulation) stop(reason StopReason) {
+	s.result.StopReason = reason
+	s.stage = structs.SimulationEnd
+}
+
+// fail stops the simulation because of an unrecoverable error and returns the error
+func (s *Simulation) fail(err error) error {
+	if err == nil {
+		err = errors.New("Unknown simulation failure.")
+	}
+	s.result.Errors = append(s.result.Errors, err)
+	s.stop(SimulationFailed)
+	return err
+}
+
+// recordEvent stamps the event with the current iteration, adds it to the result and writes it
+// to the event writer
+func (s *Simulation) recordEvent(event structs.Event) {
+	switch {
+	case event.Type == structs.SimulationEnded:
+		event.Iteration = s.iteration
+	case s.stage == structs.SpawningAliens:
+		event.Iteration = -1
+	default:
+		event.Iteration = s.iteration
+	}
+
+	switch event.Type {
+	case structs.FightOccurred:
+		s.result.Fights = append(s.result.Fights, Fight{
+			Iteration:    event.Iteration,
+			City:         event.City,
+			From:         event.From,
+			AlienIDs:     event.AlienIDs,
+			Survivors:    event.Survivors,
+			CitySurvived: event.CitySurvived,
+		})
+	case structs.CityDestroyed:
+		s.result.DestroyedCities = append(s.result.DestroyedCities, event.City)
+	}
+
+	s.notifyEvent(event)
+
+	if s.eventEncoder != nil {
+		if err := s.eventEncoder.Encode(event); err != nil {
+			s.debugLogger.Err(err).Msg("Unable to write event.")
+			s.result.Errors = append(s.result.Errors, err)
+			// stop writing events after the first failure
+			s.eventEncoder = nil
+		}
+	}
+}
+
+// finish completes the simulation result with the final state of the world
+func (s *Simulation) finish() *SimulationResult {
+	s.done = true
+	s.result.FightResolver = s.world.FightResolver().String()
+	s.recordEvent(structs.Event{Type: structs.SimulationEnded, StopReason: s.result.StopReason.String(), Resolver: s.result.FightResolver})
+	s.result.Iterations = s.iteration
+	s.result.World = s.world
+	if s.dotFileName != "" {
+		s.renderDOT(s.dotFileName)
+	}
+
+	s.result.SurvivingAliens = []AlienInfo{}
+	aliens, _ := s.world.GetAllAliens()
+	for _, alien := range aliens {
+		free, _ := s.world.IsAlienFree(alien)
+		s.result.SurvivingAliens = append(s.result.SurvivingAliens, AlienInfo{
+			ID:       alien.ID,
+			Name:     alien.Name,
+			Location: alien.Location.Name,
+			Trapped:  !free,
+		})
+	}
+	for _, observer := range s.observers {
+		observer.OnEnd(s.result)
+	}
+	s.closeLogs()
+	return s.result
+}
+
+// closeLogs closes the log files, flushing whatever was logged to them
+func (s *Simulation) closeLogs() {
+	for _, logger := range []log.Logger{s.defaultLogger, s.debugLogger} {
+		if closer, ok := logger.Writer.(io.Closer); ok {
+			closer.Close()
+		}
 	}
 }
@@ -0,0 +1,339 @@
+package simulation
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
+	"github.com/AleksandarHr/AlienInvasion/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeMapFile writes the given map file contents into a temporary file and returns its name
+func writeMapFile(t *testing.T, contents string) string {
+	fileName := filepath.Join(t.TempDir(), "map.txt")
+	if err := os.WriteFile(fileName, []byte(contents), 0600); err != nil {
+		t.Fatalf("Writing map file failed: %v", err)
+	}
+	return fileName
+}
+
+func TestRunAllAliensDead(t *testing.T) {
+	assert := assert.New(t)
+
+	simulation, err := CreateSimulation(Config{AliensCount: 2, MapFileName: writeMapFile(t, "Foo\n"), MaxIterations: 10})
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	err = simulation.InitializeSimulation()
+	assert.Nil(err, "Initializing the simulation should not fail.")
+
+	result := simulation.Run(context.Background())
+	assert.Equal(AllAliensDead, result.StopReason, "Both aliens should have died while spawning.")
+	assert.Equal(0, result.Iterations, "No iterations should have been simulated.")
+	assert.Equal([]string{"Foo"}, result.DestroyedCities, "Foo should have been destroyed.")
+	assert.Equal([]Fight{{Iteration: -1, City: "Foo", AlienIDs: []int{0, 1}}}, result.Fights, "One fight should have taken place.")
+	assert.Empty(result.SurvivingAliens, "No aliens should have survived.")
+	assert.Empty(result.Errors, "No errors should have been encountered.")
+}
+
+func TestRunNoCitiesLeft(t *testing.T) {
+	assert := assert.New(t)
+
+	simulation, err := CreateSimulation(Config{AliensCount: 3, MapFileName: writeMapFile(t, "Foo\n"), MaxIterations: 10})
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	err = simulation.InitializeSimulation()
+	assert.Nil(err, "Initializing the simulation should not fail.")
+
+	result := simulation.Run(context.Background())
+	assert.Equal(NoCitiesLeft, result.StopReason, "The third alien should have had nowhere to spawn.")
+}
+
+func TestRunAllAliensTrapped(t *testing.T) {
+	assert := assert.New(t)
+
+	simulation, err := CreateSimulation(Config{AliensCount: 1, MapFileName: writeMapFile(t, "Foo\n"), MaxIterations: 10})
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	err = simulation.InitializeSimulation()
+	assert.Nil(err, "Initializing the simulation should not fail.")
+
+	result := simulation.Run(context.Background())
+	assert.Equal(AllAliensTrapped, result.StopReason, "The only alien should be trapped.")
+	assert.Equal(1, len(result.SurvivingAliens), "The alien should have survived.")
+	assert.Equal("Foo", result.SurvivingAliens[0].Location, "The alien should be in Foo.")
+	assert.True(result.SurvivingAliens[0].Trapped, "The alien should be trapped.")
+}
+
+func TestSpawnStrategies(t *testing.T) {
+	assert := assert.New(t)
+
+	mapFileName := writeMapFile(t, "Foo north=Bar\nBar south=Foo\n")
+	simulation, err := CreateSimulation(Config{AliensCount: 3, MapFileName: mapFileName, MaxIterations: 10, SpawnStrategy: structs.NoCollisionSpawnStrategy{}})
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	err = simulation.InitializeSimulation()
+	assert.IsType(&structs.TooManyAliensError{}, err, "Three aliens cannot spawn in distinct cities of two.")
+	assert.Equal(SimulationFailed, simulation.Run(context.Background()).StopReason, "The simulation should fail before spawning.")
+
+	placement := structs.PlacementSpawnStrategy{Placements: map[int]string{0: "Bar", 1: "Foo"}}
+	simulation, err = CreateSimulation(Config{AliensCount: 2, MapFileName: mapFileName, MaxIterations: 0, SpawnStrategy: placement})
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	err = simulation.InitializeSimulation()
+	assert.Nil(err, "Initializing the simulation should not fail.")
+	result := simulation.Run(context.Background())
+	assert.Equal("Bar", result.SurvivingAliens[0].Location, "Alien 0 should spawn where it is placed.")
+	assert.Equal("Foo", result.SurvivingAliens[1].Location, "Alien 1 should spawn where it is placed.")
+}
+
+func TestRunWaves(t *testing.T) {
+	assert := assert.New(t)
+
+	// the stopping conditions wait for the waves: the first alien arrives trapped and the second one fights it
+	mapFileName := writeMapFile(t, "Foo\n")
+	waves, _ := ParseWaveSchedule("1@2,1@4", 0)
+	var events strings.Builder
+	simulation, err := CreateSimulation(Config{AliensCount: 0, Waves: waves, MapFileName: mapFileName, MaxIterations: 10, EventWriter: &events})
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	simulation.InitializeSimulation()
+	result := simulation.Run(context.Background())
+	assert.Equal(AllAliensDead, result.StopReason, "Both aliens should have died once the last wave arrived.")
+	assert.Equal(4, result.Iterations, "The simulation should have waited for the last wave.")
+	assert.Equal([]Fight{{Iteration: 4, City: "Foo", AlienIDs: []int{0, 1}}}, result.Fights, "The second wave should fight the first one.")
+
+	// waves spawned during the iterations are replayed
+	replayer, err := ReplayEvents(map[string][]string{"Foo": {}}, nil, strings.NewReader(events.String()), nil)
+	assert.Nil(err, "A run with waves should replay without problems.")
+	assert.True(replayer.Ended())
+
+	// no more waves arrive once the maximum number of aliens has spawned
+	waves, _ = ParseWaveSchedule("1@1/1", 2)
+	simulation, _ = CreateSimulation(Config{AliensCount: 1, Waves: waves, MapFileName: writeMapFile(t, "Foo north=Bar\nBar south=Foo\nBaz\n"), MaxIterations: 50, Seed: 3})
+	simulation.InitializeSimulation()
+	result = simulation.Run(context.Background())
+	assert.LessOrEqual(len(result.SurvivingAliens), 2, "At most two aliens should have spawned.")
+	for _, alien := range result.SurvivingAliens {
+		assert.Less(alien.ID, 2, "Only aliens 0 and 1 should have spawned.")
+	}
+}
+
+func TestRunSimultaneousMoves(t *testing.T) {
+	assert := assert.New(t)
+
+	mapFileName := writeMapFile(t, "Foo north=Bar\nBar south=Foo\n")
+	for seed := int64(0); seed < 10; seed++ {
+		simulation, err := CreateSimulation(Config{AliensCount: 2, MapFileName: mapFileName, MaxIterations: 10, Seed: seed, Movement: Simultaneous})
+		if err != nil {
+			t.Fatalf("Creating simulation failed: %v", err)
+		}
+		simulation.InitializeSimulation()
+		result := simulation.Run(context.Background())
+		if result.Iterations == 0 {
+			// both aliens spawned in the same city
+			continue
+		}
+
+		// the aliens swap cities in the first iteration and meet on the road
+		assert.Equal(AllAliensDead, result.StopReason, "Both aliens should have died on the road.")
+		assert.Equal(1, result.Iterations, "The aliens should have met in the first iteration.")
+		assert.Equal([]Fight{{Iteration: 0, City: "Foo", From: "Bar", AlienIDs: []int{0, 1}}}, result.Fights, "The aliens should have fought on the road.")
+		assert.Empty(result.DestroyedCities, "Only the road should have been destroyed.")
+	}
+}
+
+func TestParseMovementMode(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, mode := range []MovementMode{Sequential, Simultaneous} {
+		parsed, err := ParseMovementMode(mode.String())
+		assert.Nil(err, "Movement mode %s should be parsed.", mode)
+		assert.Equal(mode, parsed)
+	}
+	_, err := ParseMovementMode("random")
+	assert.IsType(&InvalidMovementModeError{}, err, "Unknown movement modes should be rejected.")
+}
+
+func TestRunMaxIterationsReached(t *testing.T) {
+	assert := assert.New(t)
+
+	simulation, err := CreateSimulation(Config{AliensCount: 1, MapFileName: writeMapFile(t, "Foo north=Bar\nBar south=Foo\n"), MaxIterations: 7})
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	err = simulation.InitializeSimulation()
+	assert.Nil(err, "Initializing the simulation should not fail.")
+
+	result := simulation.Run(context.Background())
+	assert.Equal(MaxIterationsReached, result.StopReason, "A lone alien should move until the iterations run out.")
+	assert.Equal(7, result.Iterations, "All iterations should have been simulated.")
+	assert.Equal(1, len(result.SurvivingAliens), "The alien should have survived.")
+	assert.False(result.SurvivingAliens[0].Trapped, "The alien should not be trapped.")
+}
+
+func TestRunIsReproducible(t *testing.T) {
+	assert := assert.New(t)
+
+	mapFileName := writeMapFile(t, "Foo north=Bar west=Baz south=Qu-ux\n"+
+		"Bar south=Foo west=Bee\n"+
+		"Baz east=Foo\n"+
+		"Qu-ux north=Foo\n"+
+		"Bee east=Bar\n")
+
+	runWithSeed := func(seed int64) (*SimulationResult, string) {
+		simulation, err := CreateSimulation(Config{AliensCount: 3, MapFileName: mapFileName, MaxIterations: 50, Seed: seed})
+		if err != nil {
+			t.Fatalf("Creating simulation failed: %v", err)
+		}
+		simulation.InitializeSimulation()
+		result := simulation.Run(context.Background())
+
+		var remainingWorld strings.Builder
+		result.World.WriteMap(&remainingWorld)
+		result.World = nil
+		return result, remainingWorld.String()
+	}
+
+	for seed := int64(0); seed < 10; seed++ {
+		result, remainingWorld := runWithSeed(seed)
+		sameSeedResult, sameSeedRemainingWorld := runWithSeed(seed)
+		assert.Equal(result, sameSeedResult, "The same seed should produce the same result.")
+		assert.Equal(remainingWorld, sameSeedRemainingWorld, "The same seed should leave the same world.")
+	}
+}
+
+func TestRunRendersDOT(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("out/world-start.dot", DOTFileNameAt("out/world.dot", -1))
+	assert.Equal("world-12", DOTFileNameAt("world", 12))
+
+	dotFileName := filepath.Join(t.TempDir(), "world.dot")
+	simulation, err := CreateSimulation(Config{AliensCount: 1, MapFileName: writeMapFile(t, "Foo north=Bar\nBar south=Foo\n"), MaxIterations: 3,
+		DOTFileName: dotFileName, DOTIterations: []int{-1, 1, 5}})
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	simulation.InitializeSimulation()
+	simulation.Run(context.Background())
+
+	for _, fileName := range []string{dotFileName, DOTFileNameAt(dotFileName, -1), DOTFileNameAt(dotFileName, 1)} {
+		contents, err := os.ReadFile(fileName)
+		assert.Nil(err, "The world should have been rendered to %s.", fileName)
+		assert.Contains(string(contents), "\"Bar\":s -- \"Foo\":n [label=\"south\"];", "The road should be rendered.")
+	}
+	_, err = os.Stat(DOTFileNameAt(dotFileName, 5))
+	assert.True(os.IsNotExist(err), "Iterations which were not simulated should not be rendered.")
+}
+
+func TestStep(t *testing.T) {
+	assert := assert.New(t)
+
+	mapFileName := writeMapFile(t, "Foo north=Bar west=Baz south=Qu-ux\n"+
+		"Bar south=Foo west=Bee\n"+
+		"Baz east=Foo\n"+
+		"Qu-ux north=Foo\n"+
+		"Bee east=Bar\n")
+	create := func(events io.Writer) *Simulation {
+		simulation, err := CreateSimulation(Config{AliensCount: 3, MapFileName: mapFileName, MaxIterations: 20, Seed: 4, EventWriter: events})
+		if err != nil {
+			t.Fatalf("Creating simulation failed: %v", err)
+		}
+		simulation.InitializeSimulation()
+		return simulation
+	}
+
+	var runEvents strings.Builder
+	result := create(&runEvents).Run(context.Background())
+
+	// stepping one alien at a time makes the same moves as running the whole simulation
+	var stepEvents strings.Builder
+	simulation := create(&stepEvents)
+	for steps := 0; !simulation.Done(); steps++ {
+		moves := strings.Count(stepEvents.String(), `"type":"AlienMoved"`)
+		simulation.StepAlien()
+		assert.LessOrEqual(strings.Count(stepEvents.String(), `"type":"AlienMoved"`)-moves, 1, "A step should move at most one alien.")
+		assert.Less(steps, 100, "The simulation should end.")
+	}
+	assert.Equal(runEvents.String(), stepEvents.String(), "Stepping should record the same events as running.")
+	assert.Equal(result.StopReason, simulation.Result().StopReason)
+	assert.Equal(result.Iterations, simulation.Iteration())
+
+	simulation.Step()
+	assert.True(simulation.Done(), "Stepping a finished simulation should do nothing.")
+}
+
+func TestRunWritesEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	var events strings.Builder
+	simulation, err := CreateSimulation(Config{AliensCount: 2, MapFileName: writeMapFile(t, "Foo\n"), MaxIterations: 10, EventWriter: &events})
+	if err != nil {
+		t.Fatalf("Creating simulation failed: %v", err)
+	}
+	simulation.InitializeSimulation()
+	result := simulation.Run(context.Background())
+
+	lines := strings.Split(strings.TrimSpace(events.String()), "\n")
+	assert.Equal(6, len(lines), "Every event should be written on its own line.")
+
+	decoded := []structs.Event{}
+	for _, line := range lines {
+		var event structs.Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("Decoding event failed: %v", err)
+		}
+		decoded = append(decoded, event)
+	}
+
+	alienNames := []string{decoded[0].AlienNames[0], decoded[2].AlienNames[0]}
+	assert.Equal([]structs.Event{
+		{Type: structs.AlienSpawned, Iteration: -1, AlienIDs: []int{0}, AlienNames: alienNames[:1], City: "Foo"},
+		{Type: structs.AlienTrapped, Iteration: -1, AlienIDs: []int{0}, AlienNames: alienNames[:1], City: "Foo"},
+		{Type: structs.AlienSpawned, Iteration: -1, AlienIDs: []int{1}, AlienNames: alienNames[1:], City: "Foo"},
+		{Type: structs.FightOccurred, Iteration: -1, AlienIDs: []int{0, 1}, AlienNames: alienNames, City: "Foo", Resolver: "mutual-destruction"},
+		{Type: structs.CityDestroyed, Iteration: -1, City: "Foo"},
+		{Type: structs.SimulationEnded, Iteration: 0, StopReason: result.StopReason.String(), Resolver: "mutual-destruction"},
+	}, decoded, "The events should describe the run.")
+}
+
+// BenchmarkRun runs silent simulations on grids generated up to a million cities, with an alien for
+// every ten cities, reporting the iterations simulated and the aliens still alive at the end
+func BenchmarkRun(b *testing.B) {
+	for _, cities := range []int{10000, 100000, 1000000} {
+		m, err := mapfile.GenerateMap(mapfile.GeneratorConfig{Topology: mapfile.GridTopology, Cities: cities, Seed: 1})
+		if err != nil {
+			b.Fatalf("Generating map failed: %v", err)
+		}
+		mapInfo := m.MapInfo()
+		b.Run(strconv.Itoa(cities)+"-cities", func(b *testing.B) {
+			b.ReportAllocs()
+			iterations, survivors := 0, 0
+			for i := 0; i < b.N; i++ {
+				simulation, err := CreateSimulation(Config{AliensCount: cities / 10, MapInfo: mapInfo, MaxIterations: 10000, Seed: int64(i), Silent: true})
+				if err != nil {
+					b.Fatalf("Creating simulation failed: %v", err)
+				}
+				simulation.InitializeSimulation()
+				result := simulation.Run(context.Background())
+				iterations += result.Iterations
+				survivors += len(result.SurvivingAliens)
+			}
+			b.ReportMetric(float64(iterations)/float64(b.N), "iterations/op")
+			b.ReportMetric(float64(survivors)/float64(b.N), "survivors/op")
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package simulation
+
+type StopReason int64
+
+// enum to represent the reasons for which a simulation can stop
+const (
+	NotStopped StopReason = iota
+	MaxIterationsReached
+	AllAliensDead
+	AllAliensTrapped
+	NoCitiesLeft
+	SimulationFailed
+	Cancelled
+)
+
+// String returns a representation of the given stop reason
+func (reason StopReason) String() string {
+	switch reason {
+	case NotStopped:
+		return "Not Stopped"
+	case MaxIterationsReached:
+		return "Max Iterations Reached"
+	case AllAliensDead:
+		return "All Aliens Dead"
+	case AllAliensTrapped:
+		return "All Aliens Trapped"
+	case NoCitiesLeft:
+		return "No Cities Left"
+	case SimulationFailed:
+		return "Simulation Failed"
+	case Cancelled:
+		return "Cancelled"
+	}
+	return "Invalid Stop Reason"
+}
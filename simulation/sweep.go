@@ -0,0 +1,112 @@
+package simulation
+
+import (
+	"github.com/AleksandarHr/AlienInvasion/mapfile"
+	"github.com/AleksandarHr/AlienInvasion/structs"
+)
+
+// SweepConfig holds the parameters of a sweep over simulation configurations
+type SweepConfig struct {
+	// maps, numbers of aliens and maximum iterations whose every combination is simulated
+	MapFileNames  []string
+	AliensCounts  []int
+	MaxIterations []int
+
+	// format of the map files (text, json or yaml), matching the extension of each file name if empty
+	MapFormat string
+
+	// number of simulations of each combination
+	Runs int
+
+	// number of simulations to run in parallel, the number of CPUs if 0
+	Workers int
+
+	// base seed of every combination, so that combinations are compared on the same seeds
+	Seed int64
+
+	// strategy choosing the cities the aliens spawn in, uniformly random if not set
+	SpawnStrategy structs.SpawnStrategy
+
+	// waves of aliens spawned during the iterations, no waves if not set
+	Waves *WaveSchedule
+
+	// strategies moving the aliens, uniformly random if not set
+	MovementStrategy structs.MovementStrategy
+	AlienStrategies  map[int]structs.MovementStrategy
+
+	// resolver deciding the outcome of fights, mutual destruction if not set
+	FightResolver structs.FightResolver
+
+	// whether the aliens move one at a time or all at once
+	Movement MovementMode
+}
+
+// SweepCell holds the aggregated outcome of the simulations of one combination of a sweep
+type SweepCell struct {
+	MapFileName   string `json:"mapFileName"`
+	AliensCount   int    `json:"aliensCount"`
+	MaxIterations int    `json:"maxIterations"`
+	Runs          int    `json:"runs"`
+
+	// number of simulations which stopped for each reason
+	StopReasons map[string]int `json:"stopReasons"`
+
+	Iterations      Distribution `json:"iterations"`
+	SurvivingAliens Distribution `json:"survivingAliens"`
+	DestroyedCities Distribution `json:"destroyedCities"`
+}
+
+// RunSweep runs a batch of simulations for every combination of map, number of aliens and maximum
+// iterations, in that order, and aggregates the outcome of each combination
+func RunSweep(config SweepConfig) ([]SweepCell, error) {
+	if config.Runs < 1 {
+		return nil, &InvalidRunsError{runs: config.Runs}
+	}
+
+	cells := []SweepCell{}
+	for _, mapFileName := range config.MapFileNames {
+		// every map is parsed once and shared by all of its combinations
+		mapInfo, err := mapfile.LoadMapInfo(mapFileName, config.MapFormat)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, aliensCount := range config.AliensCounts {
+			for _, maxIterations := range config.MaxIterations {
+				report, err := RunBatch(BatchConfig{
+					Config: Config{
+						AliensCount:   aliensCount,
+						MapFileName:   mapFileName,
+						MapInfo:       mapInfo,
+						MaxIterations: maxIterations,
+						Seed:          config.Seed,
+
+						SpawnStrategy:    config.SpawnStrategy,
+						Waves:            config.Waves,
+						MovementStrategy: config.MovementStrategy,
+						AlienStrategies:  config.AlienStrategies,
+						FightResolver:    config.FightResolver,
+						Movement:         config.Movement,
+					},
+					Runs:    config.Runs,
+					Workers: config.Workers,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				cells = append(cells, SweepCell{
+					MapFileName:     mapFileName,
+					AliensCount:     aliensCount,
+					MaxIterations:   maxIterations,
+					Runs:            report.Runs,
+					StopReasons:     report.StopReasons,
+					Iterations:      report.Iterations,
+					SurvivingAliens: report.SurvivingAliens,
+					DestroyedCities: report.DestroyedCities,
+				})
+			}
+		}
+	}
+	return cells, nil
+}
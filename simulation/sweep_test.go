@@ -0,0 +1,53 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSweep(t *testing.T) {
+	assert := assert.New(t)
+
+	mapFileName := writeMapFile(t, replayTestMap)
+	config := SweepConfig{
+		MapFileNames:  []string{mapFileName},
+		AliensCounts:  []int{2, 4},
+		MaxIterations: []int{5, 50, 500},
+		Runs:          20,
+		Seed:          7,
+	}
+	cells, err := RunSweep(config)
+	assert.Nil(err, "Running the sweep should not fail.")
+	assert.Equal(6, len(cells), "Every combination should be reported.")
+
+	assert.Equal(2, cells[0].AliensCount, "Combinations should be ordered by number of aliens first.")
+	assert.Equal(5, cells[0].MaxIterations, "Combinations should be ordered by maximum iterations last.")
+	assert.Equal(4, cells[5].AliensCount)
+	assert.Equal(500, cells[5].MaxIterations)
+
+	for _, cell := range cells {
+		assert.Equal(mapFileName, cell.MapFileName)
+		assert.Equal(20, cell.Runs, "Every combination should run every simulation.")
+		assert.LessOrEqual(cell.Iterations.Max, float64(cell.MaxIterations), "No simulation should exceed its maximum iterations.")
+		assert.LessOrEqual(cell.SurvivingAliens.Max, float64(cell.AliensCount), "No simulation should gain aliens.")
+	}
+
+	batch, err := RunBatch(BatchConfig{
+		Config: Config{AliensCount: 4, MapFileName: mapFileName, MaxIterations: 50, Seed: 7},
+		Runs:   20,
+	})
+	assert.Nil(err, "Running the batch should not fail.")
+	assert.Equal(batch.DestroyedCities, cells[4].DestroyedCities, "A combination should match the batch of the same parameters.")
+
+	config.MapFileNames = []string{"non-existent.txt"}
+	_, err = RunSweep(config)
+	assert.NotNil(err, "A missing map should fail the sweep.")
+}
+
+func TestRunSweepRejectsNoRuns(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := RunSweep(SweepConfig{MapFileNames: []string{"missing.txt"}, AliensCounts: []int{2}, MaxIterations: []int{5}, Runs: -1})
+	assert.IsType(&InvalidRunsError{}, err, "A sweep should be rejected before any map is loaded.")
+}
@@ -0,0 +1,142 @@
+package simulation
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Wave holds a number of aliens spawned at the start of an iteration, again every Every iterations if Every is positive
+type Wave struct {
+	Count     int
+	Iteration int
+	Every     int
+}
+
+// WaveSchedule holds the waves of reinforcements spawned while the simulation runs
+type WaveSchedule struct {
+	Waves []Wave
+
+	// number of aliens, including the initial ones, after which no more aliens spawn; no limit if 0
+	MaxAliens int
+}
+
+// ParseWaveSchedule parses a comma-separated list of waves, each wave written as COUNT@ITERATION,
+// or as COUNT@ITERATION/EVERY for a wave repeated every EVERY iterations (e.g. 5@100/100).
+// Repeated waves need a positive maximum number of aliens
+func ParseWaveSchedule(spec string, maxAliens int) (*WaveSchedule, error) {
+	if maxAliens < 0 {
+		return nil, &InvalidWaveScheduleError{spec: spec, reason: "the maximum number of aliens cannot be negative"}
+	}
+	schedule := &WaveSchedule{MaxAliens: maxAliens}
+	for _, part := range strings.Split(spec, ",") {
+		count, timing, found := strings.Cut(strings.TrimSpace(part), "@")
+		if !found {
+			return nil, &InvalidWaveScheduleError{spec: spec, reason: "a wave is written as COUNT@ITERATION[/EVERY]"}
+		}
+		iteration, every, repeated := strings.Cut(timing, "/")
+
+		wave := Wave{}
+		var err error
+		if wave.Count, err = strconv.Atoi(count); err != nil || wave.Count <= 0 {
+			return nil, &InvalidWaveScheduleError{spec: spec, reason: "the number of aliens of a wave must be a positive integer"}
+		}
+		if wave.Iteration, err = strconv.Atoi(iteration); err != nil || wave.Iteration < 0 {
+			return nil, &InvalidWaveScheduleError{spec: spec, reason: "the iteration of a wave must be a non-negative integer"}
+		}
+		if repeated {
+			if wave.Every, err = strconv.Atoi(every); err != nil || wave.Every <= 0 {
+				return nil, &InvalidWaveScheduleError{spec: spec, reason: "the period of a wave must be a positive integer"}
+			}
+			if maxAliens == 0 {
+				return nil, &InvalidWaveScheduleError{spec: spec, reason: "repeated waves need a maximum number of aliens"}
+			}
+		}
+		schedule.Waves = append(schedule.Waves, wave)
+	}
+	return schedule, nil
+}
+
+// String returns the waves in the format they are parsed from
+func (schedule *WaveSchedule) String() string {
+	waves := []string{}
+	for _, wave := range schedule.Waves {
+		spec := strconv.Itoa(wave.Count) + "@" + strconv.Itoa(wave.Iteration)
+		if wave.Every > 0 {
+			spec += "/" + strconv.Itoa(wave.Every)
+		}
+		waves = append(waves, spec)
+	}
+	return strings.Join(waves, ",")
+}
+
+// AliensAt returns the number of aliens the waves spawn at the start of the iteration, once the given number
+// of aliens has already spawned
+func (schedule *WaveSchedule) AliensAt(iteration int, spawned int) int {
+	count := 0
+	for _, wave := range schedule.Waves {
+		if wave.spawnsAt(iteration) {
+			count += wave.Count
+		}
+	}
+	return schedule.limit(count, spawned)
+}
+
+// PendingAfter checks whether any wave spawns aliens after the iteration and before the maximum number
+// of iterations, once the given number of aliens has already spawned
+func (schedule *WaveSchedule) PendingAfter(iteration int, maxIterations int, spawned int) bool {
+	if schedule.MaxAliens > 0 && spawned >= schedule.MaxAliens {
+		return false
+	}
+	for _, wave := range schedule.Waves {
+		next := wave.Iteration
+		if iteration >= next {
+			if wave.Every == 0 {
+				continue
+			}
+			next += ((iteration-next)/wave.Every + 1) * wave.Every
+		}
+		if next < maxIterations {
+			return true
+		}
+	}
+	return false
+}
+
+// Total returns the number of aliens spawned by the end of the given number of iterations,
+// starting with the given number of initial aliens
+func (schedule *WaveSchedule) Total(initial int, maxIterations int) int {
+	count := 0
+	for _, wave := range schedule.Waves {
+		if wave.Iteration >= maxIterations {
+			continue
+		}
+		if wave.Every == 0 {
+			count += wave.Count
+			continue
+		}
+		count += ((maxIterations-1-wave.Iteration)/wave.Every + 1) * wave.Count
+	}
+	return initial + schedule.limit(count, initial)
+}
+
+// limit caps the number of aliens to spawn so that the maximum number of aliens is not exceeded
+func (schedule *WaveSchedule) limit(count int, spawned int) int {
+	if schedule.MaxAliens == 0 || spawned+count <= schedule.MaxAliens {
+		return count
+	}
+	if spawned >= schedule.MaxAliens {
+		return 0
+	}
+	return schedule.MaxAliens - spawned
+}
+
+// spawnsAt checks whether the wave spawns at the start of the iteration
+func (wave Wave) spawnsAt(iteration int) bool {
+	if iteration < wave.Iteration {
+		return false
+	}
+	if wave.Every == 0 {
+		return iteration == wave.Iteration
+	}
+	return (iteration-wave.Iteration)%wave.Every == 0
+}
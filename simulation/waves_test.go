@@ -0,0 +1,47 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWaveSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	schedule, err := ParseWaveSchedule("10@0, 5@100/100", 50)
+	assert.Nil(err, "The waves should be valid.")
+	assert.Equal(&WaveSchedule{Waves: []Wave{{Count: 10, Iteration: 0}, {Count: 5, Iteration: 100, Every: 100}}, MaxAliens: 50}, schedule)
+	assert.Equal("10@0,5@100/100", schedule.String(), "The waves should keep their format.")
+
+	for spec, maxAliens := range map[string]int{"": 0, "5": 0, "0@1": 0, "5@-1": 0, "x@1": 0, "5@1/0": 10, "5@1/x": 10, "5@100/100": 0, "5@1": -1} {
+		_, err := ParseWaveSchedule(spec, maxAliens)
+		assert.IsType(&InvalidWaveScheduleError{}, err, "Waves %q with at most %d aliens should be invalid.", spec, maxAliens)
+	}
+}
+
+func TestWaveSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	// 10 initial aliens, then 5 more every 100 iterations until there are 50
+	schedule, _ := ParseWaveSchedule("5@100/100", 50)
+	assert.Equal(0, schedule.AliensAt(0, 10), "No wave should arrive before iteration 100.")
+	assert.Equal(0, schedule.AliensAt(150, 15), "No wave should arrive between two waves.")
+	assert.Equal(5, schedule.AliensAt(200, 15), "A wave should arrive every 100 iterations.")
+	assert.Equal(3, schedule.AliensAt(800, 47), "The last wave should stop at the maximum number of aliens.")
+	assert.Equal(0, schedule.AliensAt(900, 50), "No wave should arrive once the maximum is reached.")
+
+	assert.True(schedule.PendingAfter(0, 10000, 10), "The first wave should be pending.")
+	assert.True(schedule.PendingAfter(700, 10000, 45), "The last wave should be pending.")
+	assert.False(schedule.PendingAfter(800, 10000, 50), "No wave should be pending once the maximum is reached.")
+	assert.False(schedule.PendingAfter(150, 200, 15), "No wave should be pending past the maximum iterations.")
+
+	assert.Equal(50, schedule.Total(10, 10000), "The waves should stop at the maximum number of aliens.")
+	assert.Equal(20, schedule.Total(10, 250), "Only the waves before the maximum iterations should count.")
+
+	schedule, _ = ParseWaveSchedule("1@2,2@2,1@5", 0)
+	assert.Equal(3, schedule.AliensAt(2, 0), "Waves of the same iteration should arrive together.")
+	assert.True(schedule.PendingAfter(2, 10, 3))
+	assert.False(schedule.PendingAfter(5, 10, 4))
+	assert.Equal(4, schedule.Total(0, 10))
+}
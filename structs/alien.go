@@ -3,10 +3,8 @@ package structs
 import (
 	"math/rand"
 	"strconv"
-	"time"
 
 	"github.com/AleksandarHr/AlienInvasion/utils"
-	petname "github.com/dustinkirkland/golang-petname"
 )
 
 // Alien structure to represent information about an alien
@@ -14,14 +12,17 @@ type Alien struct {
 	ID       int
 	Name     string
 	Location *City
+
+	// city the alien was in before its last move, nil if it has not moved yet
+	PreviousLocation *City
 }
 
 // CreateAlien constructs an alien with a provided integer ID
 //
-//	and a randomly generated pet name
-func CreateAlien(newAlienID int) *Alien {
+//	and a pet name generated from the provided random source
+func CreateAlien(newAlienID int, rng *rand.Rand) *Alien {
 	alien := &Alien{ID: newAlienID}
-	alienName := alien.GiveAlienPetName(3, "-")
+	alienName := alien.GiveAlienPetName(rng, 3, "-")
 	alien.Name = alienName
 	return alien
 }
@@ -31,37 +32,23 @@ func (a *Alien) MoveToCity(newLocation *City) error {
 	if newLocation == nil {
 		return &InvalidCityError{city: newLocation}
 	}
+	a.PreviousLocation = a.Location
 	a.Location = newLocation
 	return nil
 }
 
 // PickRandomNeighbourCity randomly chooses a city neighbouring the current alien location
-func (a *Alien) PickRandomNeighbourCity() (*City, error) {
-	alienCity := a.Location
-
-	if len(alienCity.Neighbours) == 0 {
+func (a *Alien) PickRandomNeighbourCity(rng *rand.Rand) (*City, error) {
+	neighbours := a.Location.neighbourCities()
+	if len(neighbours) == 0 {
 		return nil, nil
 	}
-
-	availableDirections := []Direction{}
-	for dir := range alienCity.Neighbours {
-		availableDirections = append(availableDirections, dir)
-	}
-
-	directionIndex, err := utils.GenerateRandomNumber(len(availableDirections))
-	if err != nil {
-		return nil, err
-	}
-	randomDirection := availableDirections[directionIndex]
-	randomNextCity := alienCity.Neighbours[randomDirection]
-
-	return randomNextCity, nil
+	return pickRandomCity(neighbours, rng)
 }
 
-// GiveAlienPetName generates a petname for the alien
-func (a *Alien) GiveAlienPetName(wordCount int, nameSeparator string) string {
-	rand.Seed(time.Now().UnixNano())
-	alienName := petname.Generate(wordCount, nameSeparator) + "_" + strconv.Itoa(a.ID)
+// GiveAlienPetName generates a petname for the alien from the provided random source
+func (a *Alien) GiveAlienPetName(rng *rand.Rand, wordCount int, nameSeparator string) string {
+	alienName := utils.GeneratePetName(rng, wordCount, nameSeparator) + "_" + strconv.Itoa(a.ID)
 	a.Name = alienName
 	return alienName
 }
@@ -11,12 +11,17 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// newTestRand returns a seeded random source so that tests are reproducible
+func newTestRand() *rand.Rand {
+	return rand.New(rand.NewSource(1))
+}
+
 func TestCreateAlien(t *testing.T) {
 
 	rand.Seed(time.Now().UnixNano())
 	randomAlienID := rand.Intn(100)
 
-	alien := CreateAlien(randomAlienID)
+	alien := CreateAlien(randomAlienID, newTestRand())
 	assert.Equal(t, alien.ID, randomAlienID, "The two numbers should be the same.")
 
 }
@@ -27,7 +32,7 @@ func TestMoveAlienToCity(t *testing.T) {
 	str := "encode this string"
 	randomString := base64.StdEncoding.EncodeToString([]byte(str))
 
-	alien := CreateAlien(1)
+	alien := CreateAlien(1, newTestRand())
 	alienCity := CreateCity(randomString)
 	err := alien.MoveToCity(alienCity)
 	if err != nil {
@@ -44,7 +49,7 @@ func TestMoveAlienToCity(t *testing.T) {
 func TestPickRandomNeighbourOfIsolatedCity(t *testing.T) {
 	assert := assert.New(t)
 
-	alien := CreateAlien(1)
+	alien := CreateAlien(1, newTestRand())
 	alienCity := CreateCity("RandomCityName")
 	err := alien.MoveToCity(alienCity)
 	if err != nil {
@@ -52,21 +57,21 @@ func TestPickRandomNeighbourOfIsolatedCity(t *testing.T) {
 	}
 
 	assert.Equal(len(alien.Location.Neighbours), 0, "The city should have 0 neighbours.")
-	randomNeighbour, _ := alien.PickRandomNeighbourCity()
+	randomNeighbour, _ := alien.PickRandomNeighbourCity(newTestRand())
 	assert.Nil(randomNeighbour, "Alien is trapped, no city should have been chosen.")
 }
 
 func TestPickRandomNeighbour(t *testing.T) {
 	assert := assert.New(t)
 
-	alien := CreateAlien(1)
+	alien := CreateAlien(1, newTestRand())
 	alienCity := CreateCity("RandomCityName")
 	neighbourCity := CreateCity("NeighbourCityName")
 	alienCity.AddNeighbour(North, neighbourCity)
 	alien.MoveToCity(alienCity)
 
 	assert.Equal(len(alien.Location.Neighbours), 1, "The city should have 1 neighbour.")
-	randomNeighbour, _ := alien.PickRandomNeighbourCity()
+	randomNeighbour, _ := alien.PickRandomNeighbourCity(newTestRand())
 	assert.NotNil(randomNeighbour, "Alien is not trapped, a neighbour city should have been chosen.")
 	assert.Equal(randomNeighbour, neighbourCity, "Cities should be the same.")
 }
@@ -76,11 +81,45 @@ func TestGiveAlienPetName(t *testing.T) {
 
 	rand.Seed(time.Now().UnixNano())
 	randomAlienID := rand.Intn(100)
-	alien := CreateAlien(randomAlienID)
+	alien := CreateAlien(randomAlienID, newTestRand())
 
 	randomAlienNameWordCount := rand.Intn(3) + 1
-	alienName := alien.GiveAlienPetName(randomAlienNameWordCount, "-")
+	alienName := alien.GiveAlienPetName(newTestRand(), randomAlienNameWordCount, "-")
 
 	assert.Equal(alien.Name, alienName, "The two names should be the same.")
 	assert.True(strings.HasSuffix(alien.Name, strconv.Itoa(alien.ID)), "The alien name should end with the alien ID.")
 }
+
+func TestPickRandomNeighbourIsSeeded(t *testing.T) {
+	assert := assert.New(t)
+
+	alienCity := CreateCity("RandomCityName")
+	alienCity.AddNeighbour(North, CreateCity("NorthNeighbour"))
+	alienCity.AddNeighbour(East, CreateCity("EastNeighbour"))
+	alienCity.AddNeighbour(South, CreateCity("SouthNeighbour"))
+	alienCity.AddNeighbour(West, CreateCity("WestNeighbour"))
+
+	alien := CreateAlien(1, newTestRand())
+	alien.MoveToCity(alienCity)
+
+	pickNeighbours := func(rng *rand.Rand) []string {
+		picked := []string{}
+		for i := 0; i < 20; i++ {
+			neighbour, err := alien.PickRandomNeighbourCity(rng)
+			if err != nil {
+				t.Fatalf("Picking a random neighbour failed: %v", err)
+			}
+			picked = append(picked, neighbour.Name)
+		}
+		return picked
+	}
+	assert.Equal(pickNeighbours(newTestRand()), pickNeighbours(newTestRand()), "The same seed should pick the same neighbours.")
+}
+
+func TestGiveAlienPetNameIsSeeded(t *testing.T) {
+	assert := assert.New(t)
+
+	alien := CreateAlien(7, newTestRand())
+	sameSeedAlien := CreateAlien(7, newTestRand())
+	assert.Equal(alien.Name, sameSeedAlien.Name, "The same seed should generate the same name.")
+}
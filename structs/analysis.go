@@ -0,0 +1,253 @@
+package structs
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// AnalysisOptions holds the parameters of a map analysis
+type AnalysisOptions struct {
+	// number of cities with the highest betweenness centrality to report, all of them if 0
+	Top int
+
+	// number of cities the shortest paths are measured from, every city if 0 or at least the number of cities.
+	// With fewer cities the diameter is a lower bound and the betweenness centrality an estimate
+	Samples int
+
+	// seed of the random choice of the sampled cities
+	Seed int64
+}
+
+// MapAnalysis holds the structure of the roads of a world, every road counted once for both of its directions
+type MapAnalysis struct {
+	Cities int `json:"cities"`
+	Roads  int `json:"roads"`
+
+	// numbers of cities with every number of roads, from the fewest roads
+	Degrees []DegreeCount `json:"degrees"`
+
+	// sizes of the groups of cities connected to each other, from the largest
+	Components []int `json:"components"`
+
+	// cities and roads whose loss splits a group of connected cities, sorted by name
+	ArticulationPoints []string    `json:"articulationPoints"`
+	Bridges            [][2]string `json:"bridges"`
+
+	// largest number of roads on a shortest path between two cities
+	Diameter int `json:"diameter"`
+
+	// number of cities the shortest paths were measured from, and whether those were all the cities
+	Sources int  `json:"sources"`
+	Exact   bool `json:"exact"`
+
+	// cities with the highest betweenness centrality, from the highest
+	Centrality []CityCentrality `json:"centrality"`
+}
+
+// DegreeCount holds the number of cities with the given number of roads
+type DegreeCount struct {
+	Degree int `json:"degree"`
+	Cities int `json:"cities"`
+}
+
+// CityCentrality holds the betweenness centrality of a city: the number of pairs of other cities whose
+// shortest paths lead through it, split between equally short paths, and its share of all such pairs
+type CityCentrality struct {
+	City        string  `json:"city"`
+	Betweenness float64 `json:"betweenness"`
+	Normalized  float64 `json:"normalized"`
+}
+
+// Analyze measures the structure of the roads between the standing cities. Components, articulation
+// points and bridges take time in proportion to the size of the world, while the diameter and the
+// betweenness centrality take a breadth-first search from every sampled city
+func (w *World) Analyze(options AnalysisOptions) *MapAnalysis {
+	analysis := &MapAnalysis{
+		Cities:             len(w.standingCities),
+		Degrees:            []DegreeCount{},
+		Components:         []int{},
+		ArticulationPoints: []string{},
+		Bridges:            [][2]string{},
+		Centrality:         []CityCentrality{},
+	}
+
+	degrees := make(map[int]int)
+	for _, city := range w.standingCities {
+		degree := len(w.cityConnections[city.ID])
+		degrees[degree]++
+		analysis.Roads += degree
+	}
+	analysis.Roads /= 2
+	for degree, cities := range degrees {
+		analysis.Degrees = append(analysis.Degrees, DegreeCount{Degree: degree, Cities: cities})
+	}
+	sort.Slice(analysis.Degrees, func(i, j int) bool { return analysis.Degrees[i].Degree < analysis.Degrees[j].Degree })
+
+	w.analyzeConnectivity(analysis)
+	w.analyzePaths(analysis, options)
+	return analysis
+}
+
+// analyzeConnectivity finds the components, the articulation points and the bridges with Tarjan's
+// depth-first search, walking the roads with an explicit stack so that long chains of cities do not
+// exhaust the call stack
+func (w *World) analyzeConnectivity(analysis *MapAnalysis) {
+	// order in which every city is discovered, from 1, and the earliest discovered city it reaches
+	// without going back along the road it was discovered from
+	discovered := make([]int, len(w.citiesByID))
+	low := make([]int, len(w.citiesByID))
+	parent := make([]int, len(w.citiesByID))
+	nextRoad := make([]int, len(w.citiesByID))
+	articulation := make([]bool, len(w.citiesByID))
+	discoveries := 0
+
+	for _, root := range w.standingCities {
+		if discovered[root.ID] != 0 {
+			continue
+		}
+		discoveries++
+		discovered[root.ID], low[root.ID], parent[root.ID] = discoveries, discoveries, -1
+		size, rootChildren := 1, 0
+
+		stack := []int{root.ID}
+		for len(stack) > 0 {
+			city := stack[len(stack)-1]
+			if nextRoad[city] < len(w.cityConnections[city]) {
+				neighbour := w.cityConnections[city][nextRoad[city]]
+				nextRoad[city]++
+				if discovered[neighbour] == 0 {
+					discoveries++
+					discovered[neighbour], low[neighbour], parent[neighbour] = discoveries, discoveries, city
+					size++
+					if city == root.ID {
+						rootChildren++
+					}
+					stack = append(stack, neighbour)
+				} else if neighbour != parent[city] && discovered[neighbour] < low[city] {
+					low[city] = discovered[neighbour]
+				}
+				continue
+			}
+
+			// every road of the city has been walked, report back to the city it was discovered from
+			stack = stack[:len(stack)-1]
+			from := parent[city]
+			if from < 0 {
+				continue
+			}
+			if low[city] < low[from] {
+				low[from] = low[city]
+			}
+			if low[city] > discovered[from] {
+				analysis.Bridges = append(analysis.Bridges, orderedNames(w.citiesByID[from].Name, w.citiesByID[city].Name))
+			}
+			if from != root.ID && low[city] >= discovered[from] {
+				articulation[from] = true
+			}
+		}
+		if rootChildren > 1 {
+			articulation[root.ID] = true
+		}
+		analysis.Components = append(analysis.Components, size)
+	}
+
+	for _, city := range w.standingCities {
+		if articulation[city.ID] {
+			analysis.ArticulationPoints = append(analysis.ArticulationPoints, city.Name)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(analysis.Components)))
+	sort.Strings(analysis.ArticulationPoints)
+	sort.Slice(analysis.Bridges, func(i, j int) bool {
+		one, two := analysis.Bridges[i], analysis.Bridges[j]
+		return one[0] < two[0] || (one[0] == two[0] && one[1] < two[1])
+	})
+}
+
+// orderedNames returns the two city names in alphabetical order
+func orderedNames(one, two string) [2]string {
+	if two < one {
+		return [2]string{two, one}
+	}
+	return [2]string{one, two}
+}
+
+// analyzePaths measures the diameter and the betweenness centrality with Brandes' algorithm,
+// from every city or from the sampled cities, scaling the centrality up to all cities
+func (w *World) analyzePaths(analysis *MapAnalysis, options AnalysisOptions) {
+	sources := make([]*City, len(w.standingCities))
+	copy(sources, w.standingCities)
+	sort.Slice(sources, func(i, j int) bool { return sources[i].ID < sources[j].ID })
+	analysis.Exact = options.Samples <= 0 || options.Samples >= len(sources)
+	if !analysis.Exact {
+		rng := rand.New(rand.NewSource(options.Seed))
+		rng.Shuffle(len(sources), func(i, j int) { sources[i], sources[j] = sources[j], sources[i] })
+		sources = sources[:options.Samples]
+	}
+	analysis.Sources = len(sources)
+
+	betweenness := make([]float64, len(w.citiesByID))
+	distance := make([]int, len(w.citiesByID))
+	paths := make([]float64, len(w.citiesByID))
+	dependency := make([]float64, len(w.citiesByID))
+	for i := range distance {
+		distance[i] = -1
+	}
+
+	// cities in the order they are reached from the source
+	reached := []int{}
+	for _, source := range sources {
+		reached = append(reached[:0], source.ID)
+		distance[source.ID], paths[source.ID] = 0, 1
+		for next := 0; next < len(reached); next++ {
+			city := reached[next]
+			for _, neighbour := range w.cityConnections[city] {
+				if distance[neighbour] < 0 {
+					distance[neighbour] = distance[city] + 1
+					reached = append(reached, neighbour)
+				}
+				if distance[neighbour] == distance[city]+1 {
+					paths[neighbour] += paths[city]
+				}
+			}
+		}
+		if farthest := distance[reached[len(reached)-1]]; farthest > analysis.Diameter {
+			analysis.Diameter = farthest
+		}
+
+		// the farthest cities pass their dependency back to the cities before them on their shortest paths
+		for i := len(reached) - 1; i > 0; i-- {
+			city := reached[i]
+			for _, neighbour := range w.cityConnections[city] {
+				if distance[neighbour] == distance[city]-1 {
+					dependency[neighbour] += paths[neighbour] / paths[city] * (1 + dependency[city])
+				}
+			}
+			betweenness[city] += dependency[city]
+		}
+		for _, city := range reached {
+			distance[city], paths[city], dependency[city] = -1, 0, 0
+		}
+	}
+
+	// every pair of cities is counted from both of its ends when every city is a source
+	scale := 0.5
+	if len(sources) > 0 {
+		scale *= float64(analysis.Cities) / float64(len(sources))
+	}
+	pairs := float64(analysis.Cities-1) * float64(analysis.Cities-2) / 2
+	for _, city := range w.standingCities {
+		centrality := CityCentrality{City: city.Name, Betweenness: betweenness[city.ID] * scale}
+		if pairs > 0 {
+			centrality.Normalized = centrality.Betweenness / pairs
+		}
+		analysis.Centrality = append(analysis.Centrality, centrality)
+	}
+	sort.Slice(analysis.Centrality, func(i, j int) bool {
+		one, two := analysis.Centrality[i], analysis.Centrality[j]
+		return one.Betweenness > two.Betweenness || (one.Betweenness == two.Betweenness && one.City < two.City)
+	})
+	if options.Top > 0 && options.Top < len(analysis.Centrality) {
+		analysis.Centrality = analysis.Centrality[:options.Top]
+	}
+}
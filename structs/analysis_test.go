@@ -0,0 +1,68 @@
+package structs
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyze(t *testing.T) {
+	assert := assert.New(t)
+
+	// a chain of cities leading to a triangle, and a city without roads
+	world := CreateWorld()
+	world.InitializeWorld(map[string][]string{
+		"A": {"east=B"},
+		"B": {"west=A", "east=C"},
+		"C": {"west=B", "east=D", "south=E"},
+		"D": {"west=C", "south=E"},
+		"E": {"north=C", "east=D"},
+		"F": {},
+	})
+
+	analysis := world.Analyze(AnalysisOptions{Top: 2})
+	assert.Equal(6, analysis.Cities, "Every city should be counted.")
+	assert.Equal(5, analysis.Roads, "Roads leading both ways should be counted once.")
+	assert.Equal([]DegreeCount{{0, 1}, {1, 1}, {2, 3}, {3, 1}}, analysis.Degrees, "The cities should be counted by their number of roads.")
+	assert.Equal([]int{5, 1}, analysis.Components, "The city without roads should be a component of its own.")
+	assert.Equal([]string{"B", "C"}, analysis.ArticulationPoints, "The cities along the chain should split the world.")
+	assert.Equal([][2]string{{"A", "B"}, {"B", "C"}}, analysis.Bridges, "The roads of the chain should split the world.")
+	assert.Equal(3, analysis.Diameter, "The farthest cities should be three roads apart.")
+	assert.True(analysis.Exact, "Every city should be a source.")
+	assert.Equal([]CityCentrality{
+		{City: "C", Betweenness: 4, Normalized: 0.4},
+		{City: "B", Betweenness: 3, Normalized: 0.3},
+	}, analysis.Centrality, "The cities on the most shortest paths should be reported first.")
+
+	sampled := world.Analyze(AnalysisOptions{Samples: 2, Seed: 1})
+	assert.False(sampled.Exact, "Only the sampled cities should be sources.")
+	assert.Equal(2, sampled.Sources, "Two cities should be sampled.")
+	assert.LessOrEqual(sampled.Diameter, 3, "The sampled diameter should not exceed the diameter.")
+	assert.Equal(6, len(sampled.Centrality), "Every city should be reported without a limit.")
+}
+
+func TestAnalyzeLongChain(t *testing.T) {
+	assert := assert.New(t)
+
+	mapInfo := map[string][]string{}
+	for i := 0; i < 1000; i++ {
+		roads := []string{}
+		if i > 0 {
+			roads = append(roads, "west=C"+strconv.Itoa(i-1))
+		}
+		if i < 999 {
+			roads = append(roads, "east=C"+strconv.Itoa(i+1))
+		}
+		mapInfo["C"+strconv.Itoa(i)] = roads
+	}
+	world := CreateWorld()
+	world.InitializeWorld(mapInfo)
+
+	analysis := world.Analyze(AnalysisOptions{Top: 1})
+	assert.Equal([]int{1000}, analysis.Components, "The chain should be connected.")
+	assert.Equal(998, len(analysis.ArticulationPoints), "Every city but the ends should split the chain.")
+	assert.Equal(999, len(analysis.Bridges), "Every road should split the chain.")
+	assert.Equal(999, analysis.Diameter, "The ends should be 999 roads apart.")
+	assert.Equal("C499", analysis.Centrality[0].City, "The middle of the chain should be the most central city.")
+}
@@ -0,0 +1,79 @@
+package structs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteASCII draws the world on the grid of the given layout, one row of cities per line followed by
+// a line with the roads leading south. Cities are labelled with the number of aliens in them, marked
+// with an exclamation mark if any of them is trapped (e.g. Foo[2] or Bar[1!]), and horizontal and
+// vertical roads are drawn with dashes and bars. Roads which do not fit the layout are not drawn.
+// Cities of the layout which have since been destroyed are drawn in parentheses, e.g. (Baz)
+func (w *World) WriteASCII(out io.Writer, layout *Layout) error {
+	labels := make(map[GridPosition]string)
+	width := 1
+	for position, cityName := range layout.Occupants {
+		label := cityName
+		if w.destroyedCities[cityName] {
+			label = "(" + cityName + ")"
+		}
+		if aliens := w.GetAliensInCity(cityName); len(aliens) > 0 {
+			trapped := ""
+			for _, alien := range aliens {
+				if free, _ := w.IsAlienFree(alien); !free {
+					trapped = "!"
+				}
+			}
+			label += fmt.Sprintf("[%d%s]", len(aliens), trapped)
+		}
+		labels[position] = label
+		if len(label) > width {
+			width = len(label)
+		}
+	}
+
+	writer := bufio.NewWriter(out)
+	for y := 0; y < layout.Height; y++ {
+		var cities, roads strings.Builder
+		for x := 0; x < layout.Width; x++ {
+			position := GridPosition{X: x, Y: y}
+			label := labels[position]
+			if w.roadBetween(layout, position, East) {
+				cities.WriteString(label + strings.Repeat("-", width-len(label)+3))
+			} else {
+				cities.WriteString(label + strings.Repeat(" ", width-len(label)+3))
+			}
+			if w.roadBetween(layout, position, South) {
+				roads.WriteString("|" + strings.Repeat(" ", width+2))
+			} else {
+				roads.WriteString(strings.Repeat(" ", width+3))
+			}
+		}
+		if _, err := writer.WriteString(strings.TrimRight(cities.String(), " ") + "\n"); err != nil {
+			return err
+		}
+		if y < layout.Height-1 {
+			if _, err := writer.WriteString(strings.TrimRight(roads.String(), " ") + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+// roadBetween checks whether the cities in the given position and in the next one in the given
+// direction are linked by a road in either direction
+func (w *World) roadBetween(layout *Layout, position GridPosition, dir Direction) bool {
+	city, exists := w.cities[layout.Occupants[position]]
+	if !exists {
+		return false
+	}
+	neighbour, exists := w.cities[layout.Occupants[position.step(dir)]]
+	if !exists {
+		return false
+	}
+	return city.Neighbours[dir] == neighbour || neighbour.Neighbours[dir.Opposite()] == city
+}
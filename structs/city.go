@@ -2,7 +2,11 @@ package structs
 
 // City structure to represent information about a city
 type City struct {
-	Name       string
+	Name string
+
+	// number of the city within its world, given when the city is added to the world
+	ID int
+
 	Neighbours map[Direction]*City
 }
 
@@ -36,3 +40,25 @@ func (c *City) HasNeighbours() bool {
 
 	return false
 }
+
+// DirectionTo returns the direction of the road leading to the given neighbour city,
+// or Invalid if there is no such road
+func (c *City) DirectionTo(neighbour *City) Direction {
+	for _, dir := range []Direction{North, East, South, West} {
+		if neighbour != nil && c.Neighbours[dir] == neighbour {
+			return dir
+		}
+	}
+	return Invalid
+}
+
+// neighbourCities returns the neighbouring cities in north, east, south, west order
+func (c *City) neighbourCities() []*City {
+	neighbours := []*City{}
+	for _, dir := range []Direction{North, East, South, West} {
+		if c.Neighbours[dir] != nil {
+			neighbours = append(neighbours, c.Neighbours[dir])
+		}
+	}
+	return neighbours
+}
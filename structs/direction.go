@@ -26,6 +26,36 @@ func (dir Direction) String() string {
 	return "Invalid Direction"
 }
 
+// MapKeyword returns the lowercase keyword used for the direction in map files
+func (dir Direction) MapKeyword() string {
+	switch dir {
+	case North:
+		return "north"
+	case East:
+		return "east"
+	case South:
+		return "south"
+	case West:
+		return "west"
+	}
+	return "invalid"
+}
+
+// Opposite returns the direction pointing back the opposite way
+func (dir Direction) Opposite() Direction {
+	switch dir {
+	case North:
+		return South
+	case East:
+		return West
+	case South:
+		return North
+	case West:
+		return East
+	}
+	return Invalid
+}
+
 // StringToDirection returns a direction provided a relevant string
 func StringToDirection(dir string) Direction {
 	switch dir {
@@ -0,0 +1,79 @@
+package structs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DOTOptions holds the options of a Graphviz DOT rendering of the world
+type DOTOptions struct {
+	// leaves the destroyed cities out instead of greying them out
+	OmitDestroyed bool `json:"omitDestroyed,omitempty"`
+}
+
+// dotPorts maps every direction to the compass point its roads leave a city from
+var dotPorts = map[Direction]string{North: "n", East: "e", South: "s", West: "w"}
+
+// WriteDOT renders the world as a Graphviz DOT graph. Cities are nodes labelled with the aliens
+// in them, cities holding trapped aliens are highlighted and destroyed cities are greyed out.
+// Roads are edges labelled with the direction of the second city as seen from the first one,
+// drawn once for both of their directions and with an arrow if the road is one-way
+func (w *World) WriteDOT(out io.Writer, options DOTOptions) error {
+	writer := bufio.NewWriter(out)
+	fmt.Fprintln(writer, "graph world {")
+	fmt.Fprintln(writer, "  node [shape=box];")
+
+	cities, _ := w.GetAllCities()
+	for _, city := range cities {
+		label := []string{dotEscape(city.Name)}
+		trapped := false
+		for _, alien := range w.GetAliensInCity(city.Name) {
+			alienLabel := fmt.Sprintf("#%d %s", alien.ID, alien.Name)
+			if free, _ := w.IsAlienFree(alien); !free {
+				alienLabel += " (trapped)"
+				trapped = true
+			}
+			label = append(label, dotEscape(alienLabel))
+		}
+		attributes := ""
+		if trapped {
+			attributes = ", color=red, fontcolor=red, penwidth=2"
+		}
+		fmt.Fprintf(writer, "  \"%s\" [label=\"%s\"%s];\n", dotEscape(city.Name), strings.Join(label, "\\n"), attributes)
+	}
+	if !options.OmitDestroyed {
+		for _, cityName := range w.GetDestroyedCities() {
+			fmt.Fprintf(writer, "  \"%s\" [style=\"filled,dashed\", color=grey, fillcolor=lightgrey, fontcolor=grey];\n", dotEscape(cityName))
+		}
+	}
+
+	for _, city := range cities {
+		for _, dir := range []Direction{North, East, South, West} {
+			neighbour := city.Neighbours[dir]
+			if neighbour == nil {
+				continue
+			}
+			twoWay := neighbour.Neighbours[dir.Opposite()] == city
+			// a two-way road is drawn from the city whose name comes first
+			if twoWay && neighbour.Name < city.Name {
+				continue
+			}
+			attributes := ""
+			if !twoWay {
+				attributes = ", dir=forward"
+			}
+			fmt.Fprintf(writer, "  \"%s\":%s -- \"%s\":%s [label=\"%s\"%s];\n",
+				dotEscape(city.Name), dotPorts[dir], dotEscape(neighbour.Name), dotPorts[dir.Opposite()], dir.MapKeyword(), attributes)
+		}
+	}
+
+	fmt.Fprintln(writer, "}")
+	return writer.Flush()
+}
+
+// dotEscape escapes the backslashes and quotes of a string placed in a quoted DOT identifier
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,41 @@
+package structs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDOT(t *testing.T) {
+	assert := assert.New(t)
+
+	world := CreateWorld()
+	world.InitializeWorld(map[string][]string{
+		"Foo": {"north=Bar", "west=Baz"},
+		"Bar": {"south=Foo"},
+		"Baz": {"east=Foo", "south=Qux"},
+		"Qux": {},
+	})
+	world.AddAlienToCity(&Alien{ID: 0, Name: "zero"}, world.cities["Foo"])
+	world.AddAlienToCity(&Alien{ID: 1, Name: "one"}, world.cities["Bar"])
+	world.AddAlienToCity(&Alien{ID: 2, Name: "two"}, world.cities["Bar"])
+	world.AddAlienToCity(&Alien{ID: 3, Name: "three"}, world.cities["Qux"])
+	assert.Equal([]string{"Bar"}, world.GetDestroyedCities(), "The fight should have destroyed Bar.")
+
+	var out strings.Builder
+	world.WriteDOT(&out, DOTOptions{})
+	assert.Equal("graph world {\n"+
+		"  node [shape=box];\n"+
+		"  \"Baz\" [label=\"Baz\"];\n"+
+		"  \"Foo\" [label=\"Foo\\n#0 zero\"];\n"+
+		"  \"Qux\" [label=\"Qux\\n#3 three (trapped)\", color=red, fontcolor=red, penwidth=2];\n"+
+		"  \"Bar\" [style=\"filled,dashed\", color=grey, fillcolor=lightgrey, fontcolor=grey];\n"+
+		"  \"Baz\":e -- \"Foo\":w [label=\"east\"];\n"+
+		"  \"Baz\":s -- \"Qux\":n [label=\"south\", dir=forward];\n"+
+		"}\n", out.String(), "Roads should be drawn once, one-way roads with an arrow.")
+
+	out.Reset()
+	world.WriteDOT(&out, DOTOptions{OmitDestroyed: true})
+	assert.NotContains(out.String(), "Bar", "Destroyed cities should be omitted.")
+}
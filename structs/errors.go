@@ -56,3 +56,81 @@ type AddAlienToCityError struct {
 func (err *AddAlienToCityError) Error() string {
 	return fmt.Sprintf("Cannot move Alien %d to %s.\n", err.alien.ID, err.city.Name)
 }
+
+// error triggered when reading an event of an unknown type
+type InvalidEventTypeError struct {
+	name string
+}
+
+func (err *InvalidEventTypeError) Error() string {
+	return fmt.Sprintf("Invalid event type: %s.\n", err.name)
+}
+
+// error triggered when referring to an alien which does not exist
+type NonExistentAlienError struct {
+	alienID int
+}
+
+func (err *NonExistentAlienError) Error() string {
+	return fmt.Sprintf("Alien %d does not exist.\n", err.alienID)
+}
+
+// error triggered when parsing an unknown or malformed movement strategy
+type InvalidMovementStrategyError struct {
+	name   string
+	reason string
+}
+
+func (err *InvalidMovementStrategyError) Error() string {
+	return fmt.Sprintf("Invalid movement strategy %q: %s.\n", err.name, err.reason)
+}
+
+// error triggered when parsing an unknown or malformed fight resolver
+type InvalidFightResolverError struct {
+	name   string
+	reason string
+}
+
+func (err *InvalidFightResolverError) Error() string {
+	return fmt.Sprintf("Invalid fight resolver %q: %s.\n", err.name, err.reason)
+}
+
+// error triggered when parsing an unknown or malformed spawn strategy
+type InvalidSpawnStrategyError struct {
+	name   string
+	reason string
+}
+
+func (err *InvalidSpawnStrategyError) Error() string {
+	return fmt.Sprintf("Invalid spawn strategy %q: %s.\n", err.name, err.reason)
+}
+
+// error triggered when more aliens should spawn in distinct cities than there are cities
+type TooManyAliensError struct {
+	aliensCount int
+	citiesCount int
+}
+
+func (err *TooManyAliensError) Error() string {
+	return fmt.Sprintf("Cannot spawn %d aliens in distinct cities of a world with %d cities.\n", err.aliensCount, err.citiesCount)
+}
+
+// error triggered when an alien cannot spawn where it is placed
+type InvalidPlacementError struct {
+	alienID int
+	reason  string
+}
+
+func (err *InvalidPlacementError) Error() string {
+	return fmt.Sprintf("Invalid placement of alien %d: %s.\n", err.alienID, err.reason)
+}
+
+// error triggered when restoring a world from a snapshot whose city IDs do not match its cities
+type InvalidCityIDError struct {
+	cityID int
+	reason string
+}
+
+func (err *InvalidCityIDError) Error() string {
+	return fmt.Sprintf("Invalid city ID %d: %s.\n", err.cityID, err.reason)
+}
@@ -0,0 +1,101 @@
+package structs
+
+type EventType int64
+
+// enum to represent the kinds of events taking place in the world
+const (
+	AlienSpawned EventType = iota
+	AlienMoved
+	AlienTrapped
+	FightOccurred
+	CityDestroyed
+	RoadRemoved
+	SimulationEnded
+)
+
+// String returns a representation of the given event type
+func (eventType EventType) String() string {
+	switch eventType {
+	case AlienSpawned:
+		return "AlienSpawned"
+	case AlienMoved:
+		return "AlienMoved"
+	case AlienTrapped:
+		return "AlienTrapped"
+	case FightOccurred:
+		return "FightOccurred"
+	case CityDestroyed:
+		return "CityDestroyed"
+	case RoadRemoved:
+		return "RoadRemoved"
+	case SimulationEnded:
+		return "SimulationEnded"
+	}
+	return "InvalidEvent"
+}
+
+// MarshalText represents the event type by its name in JSON
+func (eventType EventType) MarshalText() ([]byte, error) {
+	return []byte(eventType.String()), nil
+}
+
+// UnmarshalText reads the event type from its name in JSON
+func (eventType *EventType) UnmarshalText(text []byte) error {
+	for t := AlienSpawned; t <= SimulationEnded; t++ {
+		if t.String() == string(text) {
+			*eventType = t
+			return nil
+		}
+	}
+	return &InvalidEventTypeError{name: string(text)}
+}
+
+// Event holds information about something which happened in the world
+type Event struct {
+	Type EventType `json:"type"`
+
+	// iteration during which the event happened, -1 while spawning aliens.
+	// For SimulationEnded, the number of fully simulated iterations
+	Iteration int `json:"iteration"`
+
+	// aliens involved in the event
+	AlienIDs   []int    `json:"alienIds,omitempty"`
+	AlienNames []string `json:"alienNames,omitempty"`
+
+	// city the event happened in: spawn or move destination, fight location, destroyed city,
+	// city an alien got trapped in, or the destroyed end of a removed road.
+	// For a fight on a road, the end of the road whose name comes last
+	City string `json:"city,omitempty"`
+
+	// origin of a move, the city which lost a road, or the other end of the road a fight happened on
+	From string `json:"from,omitempty"`
+
+	// direction of a move, of a removed road or of the road a fight happened on, as seen from the From city
+	Direction string `json:"direction,omitempty"`
+
+	// whether a move happened at the same time as the other moves of its iteration
+	Simultaneous bool `json:"simultaneous,omitempty"`
+
+	// reason for which the simulation ended
+	StopReason string `json:"stopReason,omitempty"`
+
+	// IDs of the aliens surviving a fight, and whether the city (or road) survived it
+	Survivors    []int `json:"survivors,omitempty"`
+	CitySurvived bool  `json:"citySurvived,omitempty"`
+
+	// resolver which decided a fight, or which decided every fight of the ended simulation
+	Resolver string `json:"resolver,omitempty"`
+}
+
+// EventRecorder receives the events taking place in the world
+type EventRecorder interface {
+	RecordEvent(event Event)
+}
+
+// EventRecorderFunc allows an ordinary function to be used as an EventRecorder
+type EventRecorderFunc func(event Event)
+
+// RecordEvent calls f(event)
+func (f EventRecorderFunc) RecordEvent(event Event) {
+	f(event)
+}
@@ -0,0 +1,138 @@
+package structs
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// FightOutcome describes how a fight between aliens ends
+type FightOutcome struct {
+	// aliens which survive the fight and hold the city, the others die
+	Survivors []*Alien
+
+	// whether the city is destroyed along with its roads, killing every alien in it
+	CityDestroyed bool
+}
+
+// FightResolver decides what happens when aliens meet in a city
+type FightResolver interface {
+	// Resolve returns the outcome of the fight between the given aliens, the arriving alien last,
+	// or nil if the aliens do not fight and share the city instead
+	Resolve(city *City, aliens []*Alien, rng *rand.Rand) (*FightOutcome, error)
+
+	// String returns the name the resolver is parsed from
+	String() string
+}
+
+// MutualDestructionResolver kills every alien in the fight and destroys the city
+type MutualDestructionResolver struct{}
+
+// ProbabilisticResolver lets a random alien survive the fight and hold the city with the given
+// probability, and otherwise kills every alien in the fight and destroys the city
+type ProbabilisticResolver struct {
+	SurvivalProbability float64
+}
+
+// CitySurvivesResolver kills every alien in the fight, but leaves the city standing with the given probability
+type CitySurvivesResolver struct {
+	SurvivalProbability float64
+}
+
+// ThresholdResolver lets aliens share a city until the given number of aliens is reached,
+// at which point every alien in the city dies and the city is destroyed
+type ThresholdResolver struct {
+	Threshold int
+}
+
+// WinnerTakesCityResolver lets a random alien win the fight and hold the city, killing the others
+type WinnerTakesCityResolver struct{}
+
+// ParseFightResolver returns the resolver with the given name. The probabilistic and city-survives
+// resolvers take a probability after a colon (probabilistic:0.3), which defaults to 0.5, and
+// the threshold resolver takes the number of aliens after a colon (threshold:3)
+func ParseFightResolver(name string) (FightResolver, error) {
+	resolverName, parameter, hasParameter := strings.Cut(strings.TrimSpace(name), ":")
+
+	switch resolverName {
+	case "mutual-destruction", "winner-takes-city":
+		if hasParameter {
+			return nil, &InvalidFightResolverError{name: name, reason: "the resolver takes no parameter"}
+		}
+		if resolverName == "winner-takes-city" {
+			return WinnerTakesCityResolver{}, nil
+		}
+		return MutualDestructionResolver{}, nil
+	case "probabilistic", "city-survives":
+		probability := 0.5
+		if hasParameter {
+			var err error
+			probability, err = strconv.ParseFloat(parameter, 64)
+			if err != nil || probability < 0 || probability > 1 {
+				return nil, &InvalidFightResolverError{name: name, reason: "the probability must be a number between 0 and 1"}
+			}
+		}
+		if resolverName == "city-survives" {
+			return CitySurvivesResolver{SurvivalProbability: probability}, nil
+		}
+		return ProbabilisticResolver{SurvivalProbability: probability}, nil
+	case "threshold":
+		threshold, err := strconv.Atoi(parameter)
+		if !hasParameter || err != nil || threshold < 2 {
+			return nil, &InvalidFightResolverError{name: name, reason: "the threshold must be a number of aliens of at least 2"}
+		}
+		return ThresholdResolver{Threshold: threshold}, nil
+	}
+	return nil, &InvalidFightResolverError{name: name, reason: "unknown resolver"}
+}
+
+// Resolve kills every alien and destroys the city
+func (MutualDestructionResolver) Resolve(city *City, aliens []*Alien, rng *rand.Rand) (*FightOutcome, error) {
+	return &FightOutcome{CityDestroyed: true}, nil
+}
+
+func (MutualDestructionResolver) String() string {
+	return "mutual-destruction"
+}
+
+// Resolve lets a random alien survive with the survival probability
+func (resolver ProbabilisticResolver) Resolve(city *City, aliens []*Alien, rng *rand.Rand) (*FightOutcome, error) {
+	if rng.Float64() >= resolver.SurvivalProbability {
+		return &FightOutcome{CityDestroyed: true}, nil
+	}
+	return &FightOutcome{Survivors: []*Alien{aliens[rng.Intn(len(aliens))]}}, nil
+}
+
+func (resolver ProbabilisticResolver) String() string {
+	return "probabilistic:" + strconv.FormatFloat(resolver.SurvivalProbability, 'g', -1, 64)
+}
+
+// Resolve kills every alien and leaves the city standing with the survival probability
+func (resolver CitySurvivesResolver) Resolve(city *City, aliens []*Alien, rng *rand.Rand) (*FightOutcome, error) {
+	return &FightOutcome{CityDestroyed: rng.Float64() >= resolver.SurvivalProbability}, nil
+}
+
+func (resolver CitySurvivesResolver) String() string {
+	return "city-survives:" + strconv.FormatFloat(resolver.SurvivalProbability, 'g', -1, 64)
+}
+
+// Resolve starts a fight only once the threshold is reached
+func (resolver ThresholdResolver) Resolve(city *City, aliens []*Alien, rng *rand.Rand) (*FightOutcome, error) {
+	if len(aliens) < resolver.Threshold {
+		return nil, nil
+	}
+	return &FightOutcome{CityDestroyed: true}, nil
+}
+
+func (resolver ThresholdResolver) String() string {
+	return "threshold:" + strconv.Itoa(resolver.Threshold)
+}
+
+// Resolve picks a random winner
+func (WinnerTakesCityResolver) Resolve(city *City, aliens []*Alien, rng *rand.Rand) (*FightOutcome, error) {
+	return &FightOutcome{Survivors: []*Alien{aliens[rng.Intn(len(aliens))]}}, nil
+}
+
+func (WinnerTakesCityResolver) String() string {
+	return "winner-takes-city"
+}
@@ -0,0 +1,126 @@
+package structs
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFightResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, name := range []string{"mutual-destruction", "probabilistic:0.25", "city-survives:0.75", "threshold:2", "threshold:3", "winner-takes-city"} {
+		resolver, err := ParseFightResolver(name)
+		assert.Nil(err, "Resolver %s should be parsed.", name)
+		assert.Equal(name, resolver.String(), "Resolver %s should keep its name.", name)
+	}
+
+	resolver, err := ParseFightResolver("probabilistic")
+	assert.Nil(err)
+	assert.Equal(ProbabilisticResolver{SurvivalProbability: 0.5}, resolver, "The survival probability should default to one half.")
+
+	for _, name := range []string{"", "duel", "probabilistic:2", "city-survives:x", "threshold", "threshold:1", "winner-takes-city:1"} {
+		_, err := ParseFightResolver(name)
+		assert.IsType(&InvalidFightResolverError{}, err, "Resolver %q should be rejected.", name)
+	}
+}
+
+func TestFightResolvers(t *testing.T) {
+	assert := assert.New(t)
+
+	city := CreateCity("Foo")
+	aliens := []*Alien{{ID: 0}, {ID: 1}}
+	rng := newTestRand()
+
+	outcome, err := MutualDestructionResolver{}.Resolve(city, aliens, rng)
+	assert.Nil(err)
+	assert.Equal(&FightOutcome{CityDestroyed: true}, outcome, "Every alien should die along with the city.")
+
+	outcome, _ = ThresholdResolver{Threshold: 2}.Resolve(city, aliens, rng)
+	assert.Equal(&FightOutcome{CityDestroyed: true}, outcome, "Reaching the threshold should start a fight.")
+	outcome, _ = ThresholdResolver{Threshold: 3}.Resolve(city, aliens, rng)
+	assert.Nil(outcome, "Aliens below the threshold should not fight.")
+
+	outcome, _ = ProbabilisticResolver{SurvivalProbability: 0}.Resolve(city, aliens, rng)
+	assert.Equal(&FightOutcome{CityDestroyed: true}, outcome, "Nobody should survive with a probability of 0.")
+	outcome, _ = CitySurvivesResolver{SurvivalProbability: 1}.Resolve(city, aliens, rng)
+	assert.Equal(&FightOutcome{}, outcome, "The city should always survive with a probability of 1.")
+
+	winners := map[int]int{}
+	survivals := 0
+	for i := 0; i < 1000; i++ {
+		outcome, _ := WinnerTakesCityResolver{}.Resolve(city, aliens, rng)
+		assert.Equal(1, len(outcome.Survivors), "There should be exactly one winner.")
+		assert.False(outcome.CityDestroyed, "The winner should take the city.")
+		winners[outcome.Survivors[0].ID]++
+
+		outcome, _ = ProbabilisticResolver{SurvivalProbability: 0.3}.Resolve(city, aliens, rng)
+		if len(outcome.Survivors) > 0 {
+			assert.False(outcome.CityDestroyed, "A survivor should hold the city.")
+			survivals++
+		}
+	}
+	assert.InDelta(500, winners[0], 80, "Either alien should be as likely to win.")
+	assert.InDelta(300, survivals, 60, "An alien should survive with the given probability.")
+}
+
+// fixedWinnerResolver lets the alien with the given ID win every fight
+type fixedWinnerResolver struct {
+	winnerID int
+}
+
+func (resolver fixedWinnerResolver) Resolve(city *City, aliens []*Alien, rng *rand.Rand) (*FightOutcome, error) {
+	for _, alien := range aliens {
+		if alien.ID == resolver.winnerID {
+			return &FightOutcome{Survivors: []*Alien{alien}}, nil
+		}
+	}
+	return &FightOutcome{CityDestroyed: true}, nil
+}
+
+func (resolver fixedWinnerResolver) String() string {
+	return "fixed-winner"
+}
+
+func TestFightOutcomes(t *testing.T) {
+	assert := assert.New(t)
+
+	mapInfo := map[string][]string{"Foo": {"north=Bar"}, "Bar": {"south=Foo"}}
+	for _, test := range []struct {
+		resolver     FightResolver
+		survivorID   int
+		citySurvives bool
+	}{
+		{MutualDestructionResolver{}, -1, false},
+		{CitySurvivesResolver{SurvivalProbability: 1}, -1, true},
+		{fixedWinnerResolver{winnerID: 0}, 0, true},
+		{fixedWinnerResolver{winnerID: 1}, 1, true},
+	} {
+		world := CreateWorld()
+		world.InitializeWorld(mapInfo)
+		world.SetFightResolver(test.resolver, newTestRand())
+
+		existing, arriving := &Alien{ID: 0}, &Alien{ID: 1}
+		world.AddAlienToCity(existing, world.cities["Foo"])
+		world.AddAlienToCity(arriving, world.cities["Bar"])
+		added, err := world.AddAlienToCity(arriving, world.cities["Foo"])
+		assert.Nil(err)
+		assert.Equal(test.survivorID == arriving.ID, added, "The move should succeed only if the arriving alien survives.")
+
+		_, cityStands := world.GetCity("Foo")
+		assert.Equal(test.citySurvives, cityStands, "%s should decide whether the city survives.", test.resolver)
+
+		aliens, _ := world.GetAllAliens()
+		occupants := world.GetAliensInCity("Foo")
+		if test.survivorID == -1 {
+			assert.Equal(0, len(aliens), "%s should kill both aliens.", test.resolver)
+			assert.Equal(0, len(occupants), "Nobody should hold the city.")
+			continue
+		}
+		assert.Equal(1, len(aliens), "%s should leave a single alien alive.", test.resolver)
+		assert.Equal(test.survivorID, aliens[0].ID, "The winner should survive.")
+		assert.Equal(aliens, occupants, "The winner should hold the city.")
+		assert.Equal("Foo", occupants[0].Location.Name)
+	}
+}
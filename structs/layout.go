@@ -0,0 +1,185 @@
+package structs
+
+import "fmt"
+
+// GridPosition holds the coordinates of a city on the grid, X growing eastwards and Y southwards
+type GridPosition struct {
+	X int
+	Y int
+}
+
+// step returns the position one step away in the given direction
+func (pos GridPosition) step(dir Direction) GridPosition {
+	switch dir {
+	case North:
+		return GridPosition{X: pos.X, Y: pos.Y - 1}
+	case East:
+		return GridPosition{X: pos.X + 1, Y: pos.Y}
+	case South:
+		return GridPosition{X: pos.X, Y: pos.Y + 1}
+	case West:
+		return GridPosition{X: pos.X - 1, Y: pos.Y}
+	}
+	return pos
+}
+
+type LayoutConflictKind int64
+
+// enum to represent the kinds of geometry which cannot be laid out on a grid
+const (
+	InconsistentRoad LayoutConflictKind = iota
+	OverlappingCities
+)
+
+// String returns a representation of the given layout conflict kind
+func (kind LayoutConflictKind) String() string {
+	switch kind {
+	case InconsistentRoad:
+		return "inconsistent-road"
+	case OverlappingCities:
+		return "overlapping-cities"
+	}
+	return "invalid-layout-conflict"
+}
+
+// LayoutConflict holds a road or a city which does not fit the grid
+type LayoutConflict struct {
+	Kind LayoutConflictKind
+
+	// city the conflict was found in, and the other city involved
+	City  string
+	Other string
+
+	// direction of the inconsistent road leading from City to Other
+	Direction Direction
+}
+
+// String returns a representation of the conflict
+func (conflict LayoutConflict) String() string {
+	if conflict.Kind == OverlappingCities {
+		return fmt.Sprintf("%s: %s and %s are laid out in the same place", conflict.Kind, conflict.City, conflict.Other)
+	}
+	return fmt.Sprintf("%s: %s is %s of %s, but another path places it elsewhere", conflict.Kind, conflict.Other, conflict.Direction.MapKeyword(), conflict.City)
+}
+
+// Layout holds the grid positions of the cities of a world
+type Layout struct {
+	// positions of every city
+	Positions map[string]GridPosition
+
+	// cities drawn in every occupied position, the first city laid out there
+	Occupants map[GridPosition]string
+
+	// size of the grid, every position lying within [0, Width) x [0, Height)
+	Width  int
+	Height int
+
+	// roads and cities which do not fit the grid
+	Conflicts []LayoutConflict
+}
+
+// Layout assigns grid coordinates to the cities by walking their roads from the root city, so that
+// every road leads one step in its direction. Cities which cannot be reached from the root are laid
+// out from the first of them by name, each group to the east of the previous one. A road whose
+// neighbour was already placed elsewhere via another path, and a city placed where another city
+// already is, are reported as conflicts. The root defaults to the first city by name if empty
+func (w *World) Layout(rootName string) (*Layout, error) {
+	layout := &Layout{
+		Positions: make(map[string]GridPosition),
+		Occupants: make(map[GridPosition]string),
+		Conflicts: []LayoutConflict{},
+	}
+
+	cities, _ := w.GetAllCities()
+	roots := []*City{}
+	if rootName != "" {
+		root, exists := w.cities[rootName]
+		if !exists {
+			return nil, &NonExistentCityError{cityName: rootName}
+		}
+		roots = append(roots, root)
+	}
+	roots = append(roots, cities...)
+
+	// roads already checked, keyed by the city they lead from and their direction
+	checkedRoads := make(map[string]map[Direction]bool)
+	for _, root := range roots {
+		if _, placed := layout.Positions[root.Name]; placed {
+			continue
+		}
+
+		// lay the group out around the root, then move it to the east of the groups laid out so far
+		group := []*City{root}
+		positions := map[string]GridPosition{root.Name: {}}
+		for next := 0; next < len(group); next++ {
+			city := group[next]
+			for _, dir := range []Direction{North, East, South, West} {
+				neighbour := city.Neighbours[dir]
+				if neighbour == nil || checkedRoads[city.Name][dir] {
+					continue
+				}
+				// the road back is the same road, checked only once
+				if neighbour.Neighbours[dir.Opposite()] == city {
+					if checkedRoads[neighbour.Name] == nil {
+						checkedRoads[neighbour.Name] = make(map[Direction]bool)
+					}
+					checkedRoads[neighbour.Name][dir.Opposite()] = true
+				}
+
+				expected := positions[city.Name].step(dir)
+				position, placed := positions[neighbour.Name]
+				if !placed {
+					if _, laidOut := layout.Positions[neighbour.Name]; laidOut {
+						// a one-way road into a group laid out before
+						layout.Conflicts = append(layout.Conflicts, LayoutConflict{Kind: InconsistentRoad, City: city.Name, Other: neighbour.Name, Direction: dir})
+						continue
+					}
+					positions[neighbour.Name] = expected
+					group = append(group, neighbour)
+				} else if position != expected {
+					layout.Conflicts = append(layout.Conflicts, LayoutConflict{Kind: InconsistentRoad, City: city.Name, Other: neighbour.Name, Direction: dir})
+				}
+			}
+		}
+		layout.place(group, positions)
+	}
+	return layout, nil
+}
+
+// place moves the group of cities to the east of the cities laid out so far and records their positions
+func (layout *Layout) place(group []*City, positions map[string]GridPosition) {
+	minX, minY, maxX, maxY := 0, 0, 0, 0
+	for _, position := range positions {
+		if position.X < minX {
+			minX = position.X
+		}
+		if position.X > maxX {
+			maxX = position.X
+		}
+		if position.Y < minY {
+			minY = position.Y
+		}
+		if position.Y > maxY {
+			maxY = position.Y
+		}
+	}
+	offsetX := layout.Width
+	if offsetX > 0 {
+		// an empty column between groups
+		offsetX++
+	}
+
+	for _, city := range group {
+		position := GridPosition{X: positions[city.Name].X - minX + offsetX, Y: positions[city.Name].Y - minY}
+		layout.Positions[city.Name] = position
+		if occupant, occupied := layout.Occupants[position]; occupied {
+			layout.Conflicts = append(layout.Conflicts, LayoutConflict{Kind: OverlappingCities, City: city.Name, Other: occupant})
+			continue
+		}
+		layout.Occupants[position] = city.Name
+	}
+	layout.Width = offsetX + maxX - minX + 1
+	if height := maxY - minY + 1; height > layout.Height {
+		layout.Height = height
+	}
+}
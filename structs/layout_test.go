@@ -0,0 +1,76 @@
+package structs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayout(t *testing.T) {
+	assert := assert.New(t)
+
+	world := CreateWorld()
+	world.InitializeWorld(map[string][]string{
+		"Foo": {"north=Bar", "west=Baz"},
+		"Bar": {"south=Foo"},
+		"Baz": {"east=Foo"},
+		"Qux": {},
+	})
+	layout, err := world.Layout("Foo")
+	assert.Nil(err, "Laying out the world should not fail.")
+	assert.Equal(map[string]GridPosition{"Bar": {X: 1, Y: 0}, "Baz": {X: 0, Y: 1}, "Foo": {X: 1, Y: 1}, "Qux": {X: 3, Y: 0}}, layout.Positions,
+		"Roads should lead one step in their direction and unreachable cities should be laid out to the east.")
+	assert.Equal(4, layout.Width)
+	assert.Equal(2, layout.Height)
+	assert.Empty(layout.Conflicts, "A grid map should have no conflicts.")
+
+	_, err = world.Layout("Quux")
+	assert.IsType(&NonExistentCityError{}, err, "The root city should exist.")
+}
+
+func TestLayoutConflicts(t *testing.T) {
+	assert := assert.New(t)
+
+	// Foo is north of Bar and Bar is north of Foo
+	world := CreateWorld()
+	world.InitializeWorld(map[string][]string{"Foo": {"north=Bar"}, "Bar": {"north=Foo"}})
+	layout, _ := world.Layout("")
+	assert.Equal([]LayoutConflict{{Kind: InconsistentRoad, City: "Foo", Other: "Bar", Direction: North}}, layout.Conflicts,
+		"The second road should contradict the first one.")
+
+	// walking around a spiral leads back to where the walk started
+	world = CreateWorld()
+	world.InitializeWorld(map[string][]string{
+		"A": {"east=B"},
+		"B": {"west=A", "south=C"},
+		"C": {"north=B", "west=D"},
+		"D": {"east=C", "north=E"},
+		"E": {"south=D"},
+	})
+	layout, _ = world.Layout("")
+	assert.Equal([]LayoutConflict{{Kind: OverlappingCities, City: "E", Other: "A"}}, layout.Conflicts, "E should be laid out on top of A.")
+	assert.Equal("A", layout.Occupants[GridPosition{X: 0, Y: 0}], "The city laid out first should be drawn.")
+}
+
+func TestWriteASCII(t *testing.T) {
+	assert := assert.New(t)
+
+	world := CreateWorld()
+	world.InitializeWorld(map[string][]string{
+		"Foo": {"north=Bar", "west=Baz"},
+		"Bar": {"south=Foo"},
+		"Baz": {"east=Foo"},
+		"Qux": {},
+	})
+	world.AddAlienToCity(&Alien{ID: 0}, world.cities["Foo"])
+	world.AddAlienToCity(&Alien{ID: 1}, world.cities["Qux"])
+	layout, _ := world.Layout("")
+
+	var out strings.Builder
+	world.WriteASCII(&out, layout)
+	assert.Equal(""+
+		"          Bar                 Qux[1!]\n"+
+		"          |\n"+
+		"Baz-------Foo[1]\n", out.String(), "The map should be drawn with its roads and aliens.")
+}
@@ -0,0 +1,247 @@
+package structs
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/AleksandarHr/AlienInvasion/utils"
+)
+
+// MovementStrategy decides where an alien moves at each iteration
+type MovementStrategy interface {
+	// NextCity returns the neighbouring city the alien moves to, or nil if the alien stays where it is
+	NextCity(w *World, alien *Alien, rng *rand.Rand) (*City, error)
+
+	// String returns the name the strategy is parsed from
+	String() string
+}
+
+// UniformStrategy moves an alien to a neighbouring city chosen uniformly at random
+type UniformStrategy struct{}
+
+// LazyStrategy keeps an alien where it is with the given probability
+// and otherwise moves it to a neighbouring city chosen uniformly at random
+type LazyStrategy struct {
+	StayProbability float64
+}
+
+// DegreeWeightedStrategy moves an alien to a neighbouring city chosen at random,
+// with a probability proportional to the number of roads leading out of the city
+type DegreeWeightedStrategy struct{}
+
+// AvoidOccupiedStrategy moves an alien to a random neighbouring city without an alien in it,
+// falling back to any neighbouring city when all of them are occupied
+type AvoidOccupiedStrategy struct{}
+
+// SeekNearestAlienStrategy moves an alien one road closer to the nearest other alien,
+// falling back to a random neighbouring city when no other alien can be reached
+type SeekNearestAlienStrategy struct{}
+
+// NonBacktrackingStrategy moves an alien to a random neighbouring city other than the one it came from,
+// unless that city is the only neighbour
+type NonBacktrackingStrategy struct{}
+
+// ParseMovementStrategy returns the strategy with the given name. The lazy strategy takes
+// the probability of staying put after a colon (lazy:0.3), which defaults to 0.5
+func ParseMovementStrategy(name string) (MovementStrategy, error) {
+	strategyName, parameter, hasParameter := strings.Cut(strings.TrimSpace(name), ":")
+	if hasParameter && strategyName != "lazy" {
+		return nil, &InvalidMovementStrategyError{name: name, reason: "the strategy takes no parameter"}
+	}
+
+	switch strategyName {
+	case "uniform":
+		return UniformStrategy{}, nil
+	case "lazy":
+		if !hasParameter {
+			return LazyStrategy{StayProbability: 0.5}, nil
+		}
+		probability, err := strconv.ParseFloat(parameter, 64)
+		if err != nil || probability < 0 || probability > 1 {
+			return nil, &InvalidMovementStrategyError{name: name, reason: "the probability must be a number between 0 and 1"}
+		}
+		return LazyStrategy{StayProbability: probability}, nil
+	case "degree-weighted":
+		return DegreeWeightedStrategy{}, nil
+	case "avoid-occupied":
+		return AvoidOccupiedStrategy{}, nil
+	case "seek-nearest-alien":
+		return SeekNearestAlienStrategy{}, nil
+	case "non-backtracking":
+		return NonBacktrackingStrategy{}, nil
+	}
+	return nil, &InvalidMovementStrategyError{name: name, reason: "unknown strategy"}
+}
+
+// NextCity moves the alien exactly like PickRandomNeighbourCity
+func (UniformStrategy) NextCity(w *World, alien *Alien, rng *rand.Rand) (*City, error) {
+	return alien.PickRandomNeighbourCity(rng)
+}
+
+func (UniformStrategy) String() string {
+	return "uniform"
+}
+
+// NextCity keeps the alien where it is with the stay probability
+func (strategy LazyStrategy) NextCity(w *World, alien *Alien, rng *rand.Rand) (*City, error) {
+	if rng.Float64() < strategy.StayProbability {
+		return nil, nil
+	}
+	return alien.PickRandomNeighbourCity(rng)
+}
+
+func (strategy LazyStrategy) String() string {
+	return "lazy:" + strconv.FormatFloat(strategy.StayProbability, 'g', -1, 64)
+}
+
+// NextCity picks a neighbouring city weighted by its number of neighbours. If no neighbouring
+// city has a road left, every neighbouring city is as likely
+func (DegreeWeightedStrategy) NextCity(w *World, alien *Alien, rng *rand.Rand) (*City, error) {
+	neighbours := alien.Location.neighbourCities()
+	if len(neighbours) == 0 {
+		return nil, nil
+	}
+
+	totalWeight := 0
+	for _, neighbour := range neighbours {
+		totalWeight += len(neighbour.neighbourCities())
+	}
+	if totalWeight == 0 {
+		return alien.PickRandomNeighbourCity(rng)
+	}
+	pick, err := utils.GenerateRandomNumber(rng, totalWeight)
+	if err != nil {
+		return nil, err
+	}
+	for _, neighbour := range neighbours {
+		pick -= len(neighbour.neighbourCities())
+		if pick < 0 {
+			return neighbour, nil
+		}
+	}
+	return nil, nil
+}
+
+func (DegreeWeightedStrategy) String() string {
+	return "degree-weighted"
+}
+
+// NextCity picks a random unoccupied neighbouring city
+func (AvoidOccupiedStrategy) NextCity(w *World, alien *Alien, rng *rand.Rand) (*City, error) {
+	unoccupied := []*City{}
+	for _, neighbour := range alien.Location.neighbourCities() {
+		if len(w.GetAliensInCity(neighbour.Name)) == 0 {
+			unoccupied = append(unoccupied, neighbour)
+		}
+	}
+	if len(unoccupied) == 0 {
+		return alien.PickRandomNeighbourCity(rng)
+	}
+	return pickRandomCity(unoccupied, rng)
+}
+
+func (AvoidOccupiedStrategy) String() string {
+	return "avoid-occupied"
+}
+
+// NextCity picks a random neighbouring city among those closest to another alien
+func (SeekNearestAlienStrategy) NextCity(w *World, alien *Alien, rng *rand.Rand) (*City, error) {
+	closest := w.neighboursNearestToAliens(alien)
+	if len(closest) == 0 {
+		return alien.PickRandomNeighbourCity(rng)
+	}
+	return pickRandomCity(closest, rng)
+}
+
+func (SeekNearestAlienStrategy) String() string {
+	return "seek-nearest-alien"
+}
+
+// NextCity picks a random neighbouring city other than the previous location of the alien
+func (NonBacktrackingStrategy) NextCity(w *World, alien *Alien, rng *rand.Rand) (*City, error) {
+	if alien.PreviousLocation == nil {
+		return alien.PickRandomNeighbourCity(rng)
+	}
+
+	forward := []*City{}
+	for _, neighbour := range alien.Location.neighbourCities() {
+		if neighbour.Name != alien.PreviousLocation.Name {
+			forward = append(forward, neighbour)
+		}
+	}
+	if len(forward) == 0 {
+		return alien.PickRandomNeighbourCity(rng)
+	}
+	return pickRandomCity(forward, rng)
+}
+
+func (NonBacktrackingStrategy) String() string {
+	return "non-backtracking"
+}
+
+// pickRandomCity returns one of the given cities chosen uniformly at random
+func pickRandomCity(cities []*City, rng *rand.Rand) (*City, error) {
+	index, err := utils.GenerateRandomNumber(rng, len(cities))
+	if err != nil {
+		return nil, err
+	}
+	return cities[index], nil
+}
+
+// neighboursNearestToAliens returns the neighbouring cities of the alien among those closest to a city
+// holding another alien. The roads are walked from all the neighbours at once, one number of roads
+// further at a time, and only until such a city is reached, so that the walk stays around the alien
+// unless the other aliens are far away. It returns no city if no other alien can be reached
+func (w *World) neighboursNearestToAliens(alien *Alien) []*City {
+	neighbours := alien.Location.neighbourCities()
+
+	// every reached city mapped to its number of roads from the nearest neighbours, and to
+	// those neighbours, one bit for each
+	distances := make(map[int]int)
+	nearestTo := make(map[int]uint)
+	reached := []int{}
+	for i, neighbour := range neighbours {
+		if _, exists := distances[neighbour.ID]; !exists {
+			distances[neighbour.ID] = 0
+			reached = append(reached, neighbour.ID)
+		}
+		nearestTo[neighbour.ID] |= 1 << i
+	}
+
+	for distance := 0; len(reached) > 0; distance++ {
+		var found uint
+		for _, cityID := range reached {
+			for _, other := range w.citiesAliens[cityID] {
+				if other.ID != alien.ID {
+					found |= nearestTo[cityID]
+					break
+				}
+			}
+		}
+		if found != 0 {
+			closest := []*City{}
+			for i, neighbour := range neighbours {
+				if found&(1<<i) != 0 {
+					closest = append(closest, neighbour)
+				}
+			}
+			return closest
+		}
+
+		next := []int{}
+		for _, cityID := range reached {
+			for _, connection := range w.cityConnections[cityID] {
+				if _, exists := distances[connection]; !exists {
+					distances[connection] = distance + 1
+					next = append(next, connection)
+				}
+				if distances[connection] == distance+1 {
+					nearestTo[connection] |= nearestTo[cityID]
+				}
+			}
+		}
+		reached = next
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createMovementTestWorld returns a world with a hub city linked to four cities, one of which leads further east
+func createMovementTestWorld() *World {
+	world := CreateWorld()
+	world.InitializeWorld(map[string][]string{
+		"Hub":   {"north=North", "east=East", "south=South", "west=West"},
+		"North": {"south=Hub"},
+		"East":  {"west=Hub", "east=Far"},
+		"South": {"north=Hub"},
+		"West":  {"east=Hub"},
+		"Far":   {"west=East"},
+	})
+	return world
+}
+
+// spawnTestAlien places a new alien in the named city of the world
+func spawnTestAlien(world *World, id int, cityName string) *Alien {
+	alien := &Alien{ID: id, Name: "alien"}
+	city, _ := world.GetCity(cityName)
+	world.AddAlienToCity(alien, city)
+	return alien
+}
+
+func TestParseMovementStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, name := range []string{"uniform", "lazy:0.25", "degree-weighted", "avoid-occupied", "seek-nearest-alien", "non-backtracking"} {
+		strategy, err := ParseMovementStrategy(name)
+		assert.Nil(err, "Strategy %s should be parsed.", name)
+		assert.Equal(name, strategy.String(), "Strategy %s should keep its name.", name)
+	}
+
+	strategy, err := ParseMovementStrategy("lazy")
+	assert.Nil(err)
+	assert.Equal(LazyStrategy{StayProbability: 0.5}, strategy, "The lazy strategy should stay put half of the time by default.")
+
+	for _, name := range []string{"", "teleport", "lazy:1.5", "lazy:often", "uniform:2"} {
+		_, err := ParseMovementStrategy(name)
+		assert.IsType(&InvalidMovementStrategyError{}, err, "Strategy %q should be rejected.", name)
+	}
+}
+
+func TestUniformStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	alien := spawnTestAlien(world, 0, "Hub")
+
+	strategyRand, pickRand := newTestRand(), newTestRand()
+	for i := 0; i < 20; i++ {
+		next, err := UniformStrategy{}.NextCity(world, alien, strategyRand)
+		assert.Nil(err)
+		expected, _ := alien.PickRandomNeighbourCity(pickRand)
+		assert.Equal(expected, next, "The uniform strategy should move like PickRandomNeighbourCity.")
+	}
+}
+
+func TestLazyStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	alien := spawnTestAlien(world, 0, "Hub")
+	rng := newTestRand()
+
+	stayed := 0
+	for i := 0; i < 1000; i++ {
+		next, err := LazyStrategy{StayProbability: 0.3}.NextCity(world, alien, rng)
+		assert.Nil(err)
+		if next == nil {
+			stayed++
+		}
+	}
+	assert.InDelta(300, stayed, 60, "The alien should stay put with the given probability.")
+
+	never, _ := LazyStrategy{StayProbability: 0}.NextCity(world, alien, rng)
+	assert.NotNil(never, "The alien should always move when the probability is 0.")
+	always, _ := LazyStrategy{StayProbability: 1}.NextCity(world, alien, rng)
+	assert.Nil(always, "The alien should never move when the probability is 1.")
+}
+
+func TestDegreeWeightedStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	alien := spawnTestAlien(world, 0, "Hub")
+	rng := newTestRand()
+
+	picks := map[string]int{}
+	for i := 0; i < 5000; i++ {
+		next, err := DegreeWeightedStrategy{}.NextCity(world, alien, rng)
+		assert.Nil(err)
+		picks[next.Name]++
+	}
+	assert.Equal(4, len(picks), "Only neighbours should be picked.")
+	assert.InDelta(2000, picks["East"], 150, "A neighbour with two roads should be picked twice as often.")
+	assert.InDelta(1000, picks["North"], 150, "A neighbour with one road should be picked half as often.")
+}
+
+func TestDegreeWeightedStrategyDeadEnds(t *testing.T) {
+	assert := assert.New(t)
+
+	// one-way roads lead into cities without a road out
+	world := CreateWorld()
+	world.InitializeWorld(map[string][]string{
+		"Foo": {"north=Bar", "south=Baz"},
+		"Bar": {},
+		"Baz": {},
+	})
+	alien := spawnTestAlien(world, 0, "Foo")
+	rng := newTestRand()
+
+	picks := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		next, err := DegreeWeightedStrategy{}.NextCity(world, alien, rng)
+		assert.Nil(err, "Neighbours without roads should not fail the move.")
+		if next != nil {
+			picks[next.Name]++
+		}
+	}
+	assert.InDelta(500, picks["Bar"], 75, "Every dead end should be as likely.")
+	assert.InDelta(500, picks["Baz"], 75, "Every dead end should be as likely.")
+}
+
+func TestAvoidOccupiedStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	alien := spawnTestAlien(world, 0, "Hub")
+	spawnTestAlien(world, 1, "North")
+	spawnTestAlien(world, 2, "East")
+	spawnTestAlien(world, 3, "South")
+	rng := newTestRand()
+
+	for i := 0; i < 20; i++ {
+		next, err := AvoidOccupiedStrategy{}.NextCity(world, alien, rng)
+		assert.Nil(err)
+		assert.Equal("West", next.Name, "The alien should move to the only unoccupied neighbour.")
+	}
+
+	spawnTestAlien(world, 4, "West")
+	next, err := AvoidOccupiedStrategy{}.NextCity(world, alien, rng)
+	assert.Nil(err)
+	assert.NotNil(next, "The alien should still move when every neighbour is occupied.")
+}
+
+func TestSeekNearestAlienStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	alien := spawnTestAlien(world, 0, "Hub")
+	rng := newTestRand()
+
+	next, err := SeekNearestAlienStrategy{}.NextCity(world, alien, rng)
+	assert.Nil(err)
+	assert.NotNil(next, "The alien should move randomly when there is no other alien.")
+
+	spawnTestAlien(world, 1, "Far")
+	for i := 0; i < 20; i++ {
+		next, err := SeekNearestAlienStrategy{}.NextCity(world, alien, rng)
+		assert.Nil(err)
+		assert.Equal("East", next.Name, "The alien should move towards the nearest alien.")
+	}
+
+	spawnTestAlien(world, 2, "North")
+	spawnTestAlien(world, 3, "South")
+	picks := map[string]int{}
+	for i := 0; i < 50; i++ {
+		next, _ := SeekNearestAlienStrategy{}.NextCity(world, alien, rng)
+		picks[next.Name]++
+	}
+	assert.Equal(2, len(picks), "The alien should move to any of the occupied neighbours.")
+	assert.Contains(picks, "North")
+	assert.Contains(picks, "South")
+}
+
+func TestSeekNearestAlienStrategyOnGrid(t *testing.T) {
+	assert := assert.New(t)
+
+	world := CreateWorld()
+	world.InitializeWorld(gridMapInfo(12, 12))
+	rng := newTestRand()
+	for i := 0; i < 6; i++ {
+		city, _ := world.GetRandomCity(rng)
+		world.AddAlienToCity(&Alien{ID: i}, city)
+	}
+
+	// the neighbours closest to another alien, measured by a walk from every neighbour over the whole map
+	for _, alien := range world.aliens {
+		if alien == nil {
+			continue
+		}
+		expected := []string{}
+		closestDistance := -1
+		for _, neighbour := range alien.Location.neighbourCities() {
+			distances := map[int]int{neighbour.ID: 0}
+			queue := []int{neighbour.ID}
+			distance := -1
+			for len(queue) > 0 && distance < 0 {
+				cityID := queue[0]
+				queue = queue[1:]
+				for _, other := range world.citiesAliens[cityID] {
+					if other.ID != alien.ID {
+						distance = distances[cityID]
+					}
+				}
+				for _, connection := range world.cityConnections[cityID] {
+					if _, exists := distances[connection]; !exists {
+						distances[connection] = distances[cityID] + 1
+						queue = append(queue, connection)
+					}
+				}
+			}
+			if distance >= 0 && (closestDistance < 0 || distance < closestDistance) {
+				expected, closestDistance = []string{}, distance
+			}
+			if distance >= 0 && distance == closestDistance {
+				expected = append(expected, neighbour.Name)
+			}
+		}
+
+		closest := []string{}
+		for _, city := range world.neighboursNearestToAliens(alien) {
+			closest = append(closest, city.Name)
+		}
+		assert.Equal(expected, closest, "Alien %d should find the neighbours closest to another alien.", alien.ID)
+	}
+}
+
+func TestNonBacktrackingStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	alien := spawnTestAlien(world, 0, "North")
+	hub, _ := world.GetCity("Hub")
+	world.AddAlienToCity(alien, hub)
+	assert.Equal("North", alien.PreviousLocation.Name, "The alien should remember where it came from.")
+	rng := newTestRand()
+
+	for i := 0; i < 50; i++ {
+		next, err := NonBacktrackingStrategy{}.NextCity(world, alien, rng)
+		assert.Nil(err)
+		assert.NotEqual("North", next.Name, "The alien should not go back where it came from.")
+	}
+
+	north, _ := world.GetCity("North")
+	world.AddAlienToCity(alien, north)
+	next, err := NonBacktrackingStrategy{}.NextCity(world, alien, rng)
+	assert.Nil(err)
+	assert.Equal("Hub", next.Name, "The alien should go back when there is no other road.")
+}
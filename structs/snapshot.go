@@ -0,0 +1,239 @@
+package structs
+
+import "sort"
+
+// WorldSnapshot holds the full state of a world in a form which can be serialized
+type WorldSnapshot struct {
+	// standing cities along with their neighbours, keyed by direction, in the order
+	// random cities are picked from
+	Cities []CitySnapshot `json:"cities"`
+
+	// names of the destroyed cities
+	DestroyedCities []string `json:"destroyedCities,omitempty"`
+
+	// city names mapped to the names of all the cities they are linked to
+	CityConnections map[string][]string `json:"cityConnections"`
+
+	// aliens which are still alive
+	Aliens []AlienSnapshot `json:"aliens"`
+
+	// IDs of the aliens which are not trapped, in the order the world lists them
+	FreeAliens []int `json:"freeAliens"`
+
+	// city names mapped to the IDs of the aliens in the city
+	CitiesAliens map[string][]int `json:"citiesAliens"`
+
+	// IDs of the standing cities without aliens, in the order unoccupied cities are picked from.
+	// If it is missing, the restored world lists them in the order of the standing cities
+	UnoccupiedCities []int `json:"unoccupiedCities,omitempty"`
+}
+
+// CitySnapshot holds the state of a city
+type CitySnapshot struct {
+	Name       string            `json:"name"`
+	ID         int               `json:"id"`
+	Neighbours map[string]string `json:"neighbours"`
+}
+
+// AlienSnapshot holds the state of an alien
+type AlienSnapshot struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+
+	// city the alien was in before its last move, empty if it has not moved yet
+	PreviousLocation string `json:"previousLocation,omitempty"`
+}
+
+// Snapshot captures the full state of the world, in a stable order. Cities and free aliens are
+// listed in the order the world keeps them in, as are the unoccupied cities, so that the restored world makes the same random choices
+func (w *World) Snapshot() *WorldSnapshot {
+	snapshot := &WorldSnapshot{
+		Cities:          []CitySnapshot{},
+		CityConnections: make(map[string][]string),
+		Aliens:          []AlienSnapshot{},
+		FreeAliens:      []int{},
+		CitiesAliens:    make(map[string][]int),
+		DestroyedCities: w.GetDestroyedCities(),
+	}
+
+	for _, city := range w.standingCities {
+		citySnapshot := CitySnapshot{Name: city.Name, ID: city.ID, Neighbours: make(map[string]string)}
+		for dir, neighbour := range city.Neighbours {
+			if neighbour != nil {
+				citySnapshot.Neighbours[dir.MapKeyword()] = neighbour.Name
+			}
+		}
+		snapshot.Cities = append(snapshot.Cities, citySnapshot)
+	}
+
+	for _, city := range w.standingCities {
+		if len(w.cityConnections[city.ID]) == 0 {
+			continue
+		}
+		connections := []string{}
+		for _, connectionID := range w.cityConnections[city.ID] {
+			connections = append(connections, w.citiesByID[connectionID].Name)
+		}
+		sort.Strings(connections)
+		snapshot.CityConnections[city.Name] = connections
+	}
+
+	aliens, _ := w.GetAllAliens()
+	for _, alien := range aliens {
+		alienSnapshot := AlienSnapshot{ID: alien.ID, Name: alien.Name, Location: alien.Location.Name}
+		if alien.PreviousLocation != nil {
+			alienSnapshot.PreviousLocation = alien.PreviousLocation.Name
+		}
+		snapshot.Aliens = append(snapshot.Aliens, alienSnapshot)
+	}
+
+	freeAliens, _ := w.GetFreeAliens()
+	for _, alien := range freeAliens {
+		snapshot.FreeAliens = append(snapshot.FreeAliens, alien.ID)
+	}
+
+	for _, city := range w.standingCities {
+		if len(w.citiesAliens[city.ID]) == 0 {
+			continue
+		}
+		alienIDs := []int{}
+		for _, alien := range w.aliensIn(city) {
+			alienIDs = append(alienIDs, alien.ID)
+		}
+		snapshot.CitiesAliens[city.Name] = alienIDs
+	}
+
+	for _, city := range w.unoccupiedCities {
+		snapshot.UnoccupiedCities = append(snapshot.UnoccupiedCities, city.ID)
+	}
+	return snapshot
+}
+
+// RestoreWorld reconstructs a world from a snapshot taken with Snapshot
+func RestoreWorld(snapshot *WorldSnapshot) (*World, error) {
+	w := CreateWorld()
+
+	for _, citySnapshot := range snapshot.Cities {
+		if err := w.restoreCity(citySnapshot); err != nil {
+			return nil, err
+		}
+	}
+	for _, citySnapshot := range snapshot.Cities {
+		city := w.cities[citySnapshot.Name]
+		for dirName, neighbourName := range citySnapshot.Neighbours {
+			neighbour, exists := w.cities[neighbourName]
+			if !exists {
+				return nil, &NonExistentCityError{cityName: neighbourName}
+			}
+			if err := city.AddNeighbour(StringToDirection(dirName), neighbour); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, cityName := range snapshot.DestroyedCities {
+		w.destroyedCities[cityName] = true
+	}
+
+	for cityName, connections := range snapshot.CityConnections {
+		if _, exists := w.cities[cityName]; !exists {
+			return nil, &NonExistentCityError{cityName: cityName}
+		}
+		for _, connection := range connections {
+			if _, exists := w.cities[connection]; !exists {
+				return nil, &NonExistentCityError{cityName: connection}
+			}
+			w.addConnection(w.cities[cityName].ID, w.cities[connection].ID)
+		}
+	}
+
+	for _, alienSnapshot := range snapshot.Aliens {
+		location, exists := w.cities[alienSnapshot.Location]
+		if !exists {
+			return nil, &NonExistentCityError{cityName: alienSnapshot.Location}
+		}
+		alien := &Alien{ID: alienSnapshot.ID, Name: alienSnapshot.Name, Location: location}
+		if alienSnapshot.PreviousLocation != "" {
+			// the previous city may have been destroyed since, in which case it is kept outside the world
+			if previous, exists := w.cities[alienSnapshot.PreviousLocation]; exists {
+				alien.PreviousLocation = previous
+			} else {
+				alien.PreviousLocation = CreateCity(alienSnapshot.PreviousLocation)
+			}
+		}
+		if alien.ID < 0 {
+			return nil, &InvalidAlienError{alien: alien}
+		}
+		w.addAlien(alien)
+	}
+
+	for _, alienID := range snapshot.FreeAliens {
+		alien := w.restoredAlien(alienID)
+		if alien == nil {
+			return nil, &NonExistentAlienError{alienID: alienID}
+		}
+		w.freeAlien(alien)
+	}
+
+	for cityName, alienIDs := range snapshot.CitiesAliens {
+		if _, exists := w.cities[cityName]; !exists {
+			return nil, &NonExistentCityError{cityName: cityName}
+		}
+		city := w.cities[cityName]
+		for _, alienID := range alienIDs {
+			alien := w.restoredAlien(alienID)
+			if alien == nil {
+				return nil, &NonExistentAlienError{alienID: alienID}
+			}
+			w.citiesAliens[city.ID] = append(w.citiesAliens[city.ID], alien)
+		}
+	}
+
+	if snapshot.UnoccupiedCities == nil {
+		for _, city := range w.standingCities {
+			if len(w.citiesAliens[city.ID]) == 0 {
+				w.addUnoccupied(city)
+			}
+		}
+		return w, nil
+	}
+	for _, cityID := range snapshot.UnoccupiedCities {
+		if cityID < 0 || cityID >= len(w.citiesByID) || w.citiesByID[cityID] == nil || len(w.citiesAliens[cityID]) > 0 || w.unoccupiedIndex[cityID] >= 0 {
+			return nil, &InvalidCityIDError{cityID: cityID, reason: "it is not the ID of a standing city without aliens listed once"}
+		}
+		w.addUnoccupied(w.citiesByID[cityID])
+	}
+	return w, nil
+}
+
+// restoreCity adds the city with the ID it had in the snapshot, after the cities restored so far
+// in the order random cities are picked from. The IDs of destroyed cities are left unused
+func (w *World) restoreCity(citySnapshot CitySnapshot) error {
+	if citySnapshot.ID < 0 || (citySnapshot.ID < len(w.citiesByID) && w.citiesByID[citySnapshot.ID] != nil) {
+		return &InvalidCityIDError{cityID: citySnapshot.ID, reason: "the ID of city " + citySnapshot.Name + " must be distinct and non-negative"}
+	}
+	for len(w.citiesByID) <= citySnapshot.ID {
+		w.citiesByID = append(w.citiesByID, nil)
+		w.standingIndex = append(w.standingIndex, -1)
+		w.citiesAliens = append(w.citiesAliens, nil)
+		w.cityConnections = append(w.cityConnections, nil)
+		w.unoccupiedIndex = append(w.unoccupiedIndex, -1)
+	}
+
+	city := CreateCity(citySnapshot.Name)
+	city.ID = citySnapshot.ID
+	w.cities[city.Name] = city
+	w.citiesByID[city.ID] = city
+	w.standingIndex[city.ID] = len(w.standingCities)
+	w.standingCities = append(w.standingCities, city)
+	return nil
+}
+
+// restoredAlien returns the restored alien with the given ID, or nil if there is no such alien
+func (w *World) restoredAlien(alienID int) *Alien {
+	if alienID < 0 || alienID >= len(w.aliens) {
+		return nil
+	}
+	return w.aliens[alienID]
+}
@@ -0,0 +1,97 @@
+package structs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	world := CreateWorld()
+	mapInfo := map[string][]string{
+		"Foo": {"north=Bar", "west=Baz"},
+		"Bar": {"south=Foo"},
+		"Baz": {"east=Foo"},
+		"Bee": {},
+	}
+	world.InitializeWorld(mapInfo)
+	freeAlien := CreateAlien(0, newTestRand())
+	world.AddAlienToCity(freeAlien, world.cities["Bar"])
+	trappedAlien := CreateAlien(1, newTestRand())
+	world.AddAlienToCity(trappedAlien, world.cities["Bee"])
+
+	encoded, err := json.Marshal(world.Snapshot())
+	assert.Nil(err, "The snapshot should be serializable.")
+	var snapshot WorldSnapshot
+	err = json.Unmarshal(encoded, &snapshot)
+	assert.Nil(err, "The snapshot should be deserializable.")
+
+	restored, err := RestoreWorld(&snapshot)
+	assert.Nil(err, "The world should be restorable from its snapshot.")
+	assert.Equal(world.Snapshot(), restored.Snapshot(), "The restored world should have the same state.")
+
+	var worldMap, restoredMap strings.Builder
+	world.WriteMap(&worldMap)
+	restored.WriteMap(&restoredMap)
+	assert.Equal(worldMap.String(), restoredMap.String(), "The restored world should have the same map.")
+
+	restoredAlien := restored.aliens[0]
+	assert.Equal(restored.cities["Bar"], restoredAlien.Location, "Aliens should be located in the restored cities.")
+	assert.Equal([]*Alien{restoredAlien}, restored.GetAliensInCity("Bar"), "Cities should refer to the restored aliens.")
+	free, _ := restored.IsAlienFree(restored.aliens[1])
+	assert.False(free, "Trapped aliens should remain trapped.")
+}
+
+func TestRestoreWorldRejectsUnknownCities(t *testing.T) {
+	assert := assert.New(t)
+
+	snapshot := &WorldSnapshot{
+		Cities: []CitySnapshot{{Name: "Foo", Neighbours: map[string]string{"north": "Bar"}}},
+	}
+	_, err := RestoreWorld(snapshot)
+	assert.IsType(&NonExistentCityError{}, err, "Roads to unknown cities should be rejected.")
+}
+
+func TestRestoreWorldRejectsDuplicateCityIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	snapshot := &WorldSnapshot{
+		Cities: []CitySnapshot{{Name: "Foo", ID: 1, Neighbours: map[string]string{}}, {Name: "Bar", ID: 1, Neighbours: map[string]string{}}},
+	}
+	_, err := RestoreWorld(snapshot)
+	assert.IsType(&InvalidCityIDError{}, err, "Cities sharing an ID should be rejected.")
+
+	snapshot.Cities[1].ID = 0
+	restored, err := RestoreWorld(snapshot)
+	assert.Nil(err, "Cities may be listed in any order of their IDs.")
+	assert.Equal(snapshot.Cities, restored.Snapshot().Cities, "The cities should keep their IDs and order.")
+}
+
+func TestRestoreWorldKeepsUnoccupiedCities(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	spawnTestAlien(world, 0, "Hub")
+	alien := spawnTestAlien(world, 1, "North")
+	world.AddAlienToCity(alien, world.cities["Far"])
+
+	restored, err := RestoreWorld(world.Snapshot())
+	assert.Nil(err)
+	worldRand, restoredRand := newTestRand(), newTestRand()
+	for i := 2; i < 6; i++ {
+		city, _ := NoCollisionSpawnStrategy{}.SpawnCity(world, &Alien{ID: i}, worldRand)
+		restoredCity, _ := NoCollisionSpawnStrategy{}.SpawnCity(restored, &Alien{ID: i}, restoredRand)
+		assert.Equal(city.Name, restoredCity.Name, "The restored world should spawn aliens in the same cities.")
+		spawnTestAlien(world, i, city.Name)
+		spawnTestAlien(restored, i, restoredCity.Name)
+	}
+
+	snapshot := world.Snapshot()
+	snapshot.UnoccupiedCities = []int{world.cities["Hub"].ID}
+	_, err = RestoreWorld(snapshot)
+	assert.IsType(&InvalidCityIDError{}, err, "A city with aliens should not be listed as unoccupied.")
+}
@@ -0,0 +1,242 @@
+package structs
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AleksandarHr/AlienInvasion/utils"
+)
+
+// SpawnStrategy decides which city each alien spawns in
+type SpawnStrategy interface {
+	// Validate checks that the given number of aliens can be spawned in the world before any of them spawns
+	Validate(w *World, aliensCount int) error
+
+	// SpawnCity returns the city the alien spawns in, or nil if there are no cities left
+	SpawnCity(w *World, alien *Alien, rng *rand.Rand) (*City, error)
+
+	// String returns the name the strategy is parsed from
+	String() string
+}
+
+// UniformSpawnStrategy spawns an alien in a city chosen uniformly at random
+type UniformSpawnStrategy struct{}
+
+// NoCollisionSpawnStrategy spawns every alien in a random city without an alien in it
+type NoCollisionSpawnStrategy struct{}
+
+// DegreeWeightedSpawnStrategy spawns an alien in a city chosen at random, with a probability
+// proportional to the number of roads leading out of the city
+type DegreeWeightedSpawnStrategy struct{}
+
+// ClusteredSpawnStrategy spawns an alien in a random city at most Radius roads away from the Center city,
+// falling back to any city once the Center city is destroyed
+type ClusteredSpawnStrategy struct {
+	Center string
+	Radius int
+}
+
+// PlacementSpawnStrategy spawns every alien in the city it is placed in
+type PlacementSpawnStrategy struct {
+	// alien IDs mapped to the names of the cities they spawn in
+	Placements map[int]string
+}
+
+// ParseSpawnStrategy returns the spawn strategy with the given name. The clustered strategy takes
+// the center city and optionally the radius after colons (clustered:Foo:2), the radius defaulting to 1
+func ParseSpawnStrategy(name string) (SpawnStrategy, error) {
+	parts := strings.Split(strings.TrimSpace(name), ":")
+	if len(parts) > 1 && parts[0] != "clustered" {
+		return nil, &InvalidSpawnStrategyError{name: name, reason: "the strategy takes no parameter"}
+	}
+
+	switch parts[0] {
+	case "uniform":
+		return UniformSpawnStrategy{}, nil
+	case "no-collision":
+		return NoCollisionSpawnStrategy{}, nil
+	case "degree-weighted":
+		return DegreeWeightedSpawnStrategy{}, nil
+	case "clustered":
+		if len(parts) < 2 || len(parts) > 3 || parts[1] == "" {
+			return nil, &InvalidSpawnStrategyError{name: name, reason: "the strategy takes a city and an optional radius"}
+		}
+		strategy := ClusteredSpawnStrategy{Center: parts[1], Radius: 1}
+		if len(parts) == 3 {
+			radius, err := strconv.Atoi(parts[2])
+			if err != nil || radius < 0 {
+				return nil, &InvalidSpawnStrategyError{name: name, reason: "the radius must be a non-negative integer"}
+			}
+			strategy.Radius = radius
+		}
+		return strategy, nil
+	case "placement":
+		return nil, &InvalidSpawnStrategyError{name: name, reason: "placements are read from a placement file"}
+	}
+	return nil, &InvalidSpawnStrategyError{name: name, reason: "unknown strategy"}
+}
+
+// Validate accepts any number of aliens
+func (UniformSpawnStrategy) Validate(w *World, aliensCount int) error {
+	return nil
+}
+
+// SpawnCity picks the city exactly like GetRandomCity
+func (UniformSpawnStrategy) SpawnCity(w *World, alien *Alien, rng *rand.Rand) (*City, error) {
+	return w.GetRandomCity(rng)
+}
+
+func (UniformSpawnStrategy) String() string {
+	return "uniform"
+}
+
+// Validate checks that there is a city for every alien
+func (NoCollisionSpawnStrategy) Validate(w *World, aliensCount int) error {
+	if aliensCount > len(w.cities) {
+		return &TooManyAliensError{aliensCount: aliensCount, citiesCount: len(w.cities)}
+	}
+	return nil
+}
+
+// SpawnCity picks a random unoccupied city
+func (NoCollisionSpawnStrategy) SpawnCity(w *World, alien *Alien, rng *rand.Rand) (*City, error) {
+	if len(w.unoccupiedCities) == 0 {
+		return nil, &TooManyAliensError{aliensCount: alien.ID + 1, citiesCount: len(w.standingCities)}
+	}
+	return pickRandomCity(w.unoccupiedCities, rng)
+}
+
+func (NoCollisionSpawnStrategy) String() string {
+	return "no-collision"
+}
+
+// Validate accepts any number of aliens
+func (DegreeWeightedSpawnStrategy) Validate(w *World, aliensCount int) error {
+	return nil
+}
+
+// SpawnCity picks a city weighted by its number of neighbours. If no city has a road left,
+// every city is as likely
+func (DegreeWeightedSpawnStrategy) SpawnCity(w *World, alien *Alien, rng *rand.Rand) (*City, error) {
+	if w.roadWeights == nil {
+		weights := make([]int, len(w.citiesByID))
+		for _, city := range w.standingCities {
+			weights[city.ID] = len(city.neighbourCities())
+		}
+		w.roadWeights = createWeightTree(weights)
+	}
+	if w.roadWeights.total == 0 {
+		return w.GetRandomCity(rng)
+	}
+
+	pick, err := utils.GenerateRandomNumber(rng, w.roadWeights.total)
+	if err != nil {
+		return nil, err
+	}
+	return w.citiesByID[w.roadWeights.find(pick)], nil
+}
+
+func (DegreeWeightedSpawnStrategy) String() string {
+	return "degree-weighted"
+}
+
+// Validate checks that the center city exists
+func (strategy ClusteredSpawnStrategy) Validate(w *World, aliensCount int) error {
+	if _, exists := w.cities[strategy.Center]; !exists {
+		return &NonExistentCityError{cityName: strategy.Center}
+	}
+	return nil
+}
+
+// SpawnCity picks a random city within the radius of the center city
+func (strategy ClusteredSpawnStrategy) SpawnCity(w *World, alien *Alien, rng *rand.Rand) (*City, error) {
+	center, exists := w.cities[strategy.Center]
+	if !exists {
+		return w.GetRandomCity(rng)
+	}
+
+	return pickRandomCity(w.citiesWithin(center, strategy.Radius), rng)
+}
+
+func (strategy ClusteredSpawnStrategy) String() string {
+	return "clustered:" + strategy.Center + ":" + strconv.Itoa(strategy.Radius)
+}
+
+// clusterKey identifies the cities within the radius of a center city
+type clusterKey struct {
+	centerID int
+	radius   int
+}
+
+// cluster holds the cities within the radius of a center city, ordered by ID, and
+// the number of road changes in the world they were found after
+type cluster struct {
+	cities      []*City
+	roadChanges int
+}
+
+// citiesWithin returns the cities at most radius roads away from the center city, ordered by ID. The roads
+// are only walked up to the radius, and again only once a road has been removed or a city destroyed
+func (w *World) citiesWithin(center *City, radius int) []*City {
+	key := clusterKey{centerID: center.ID, radius: radius}
+	if cached, exists := w.clusters[key]; exists && cached.roadChanges == w.roadChanges {
+		return cached.cities
+	}
+
+	distances := map[int]int{center.ID: 0}
+	cities := []*City{center}
+	for next := 0; next < len(cities); next++ {
+		city := cities[next]
+		if distances[city.ID] == radius {
+			continue
+		}
+		for _, connectionID := range w.cityConnections[city.ID] {
+			if _, reached := distances[connectionID]; !reached {
+				distances[connectionID] = distances[city.ID] + 1
+				cities = append(cities, w.citiesByID[connectionID])
+			}
+		}
+	}
+	sort.Slice(cities, func(i, j int) bool { return cities[i].ID < cities[j].ID })
+
+	if w.clusters == nil {
+		w.clusters = make(map[clusterKey]*cluster)
+	}
+	w.clusters[key] = &cluster{cities: cities, roadChanges: w.roadChanges}
+	return cities
+}
+
+// Validate checks that every alien, and only those, is placed in an existing city
+func (strategy PlacementSpawnStrategy) Validate(w *World, aliensCount int) error {
+	for alienID := 0; alienID < aliensCount; alienID++ {
+		cityName, placed := strategy.Placements[alienID]
+		if !placed {
+			return &InvalidPlacementError{alienID: alienID, reason: "the alien has no city"}
+		}
+		if _, exists := w.cities[cityName]; !exists {
+			return &InvalidPlacementError{alienID: alienID, reason: "the city " + cityName + " does not exist"}
+		}
+	}
+	for alienID := range strategy.Placements {
+		if alienID >= aliensCount {
+			return &InvalidPlacementError{alienID: alienID, reason: "only " + strconv.Itoa(aliensCount) + " aliens spawn"}
+		}
+	}
+	return nil
+}
+
+// SpawnCity returns the city the alien is placed in. The city may have been destroyed by an earlier spawn
+func (strategy PlacementSpawnStrategy) SpawnCity(w *World, alien *Alien, rng *rand.Rand) (*City, error) {
+	cityName := strategy.Placements[alien.ID]
+	city, exists := w.cities[cityName]
+	if !exists {
+		return nil, &InvalidPlacementError{alienID: alien.ID, reason: "the city " + cityName + " has been destroyed"}
+	}
+	return city, nil
+}
+
+func (PlacementSpawnStrategy) String() string {
+	return "placement"
+}
@@ -0,0 +1,159 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpawnStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, name := range []string{"uniform", "no-collision", "degree-weighted", "clustered:Foo:0", "clustered:Foo:3"} {
+		strategy, err := ParseSpawnStrategy(name)
+		assert.Nil(err, "Strategy %s should be parsed.", name)
+		assert.Equal(name, strategy.String(), "Strategy %s should keep its name.", name)
+	}
+
+	strategy, err := ParseSpawnStrategy("clustered:Foo")
+	assert.Nil(err)
+	assert.Equal(ClusteredSpawnStrategy{Center: "Foo", Radius: 1}, strategy, "The radius should default to one.")
+
+	for _, name := range []string{"", "random", "uniform:1", "clustered", "clustered:", "clustered:Foo:-1", "clustered:Foo:x", "clustered:Foo:1:2", "placement"} {
+		_, err := ParseSpawnStrategy(name)
+		assert.IsType(&InvalidSpawnStrategyError{}, err, "Strategy %q should be rejected.", name)
+	}
+}
+
+func TestUniformSpawnStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	strategyRand, pickRand := newTestRand(), newTestRand()
+	for i := 0; i < 20; i++ {
+		city, err := UniformSpawnStrategy{}.SpawnCity(world, &Alien{ID: i}, strategyRand)
+		assert.Nil(err)
+		expected, _ := world.GetRandomCity(pickRand)
+		assert.Equal(expected, city, "The uniform strategy should spawn like GetRandomCity.")
+	}
+}
+
+func TestNoCollisionSpawnStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	assert.IsType(&TooManyAliensError{}, NoCollisionSpawnStrategy{}.Validate(world, 7), "More aliens than cities should be rejected.")
+	assert.Nil(NoCollisionSpawnStrategy{}.Validate(world, 6), "An alien for every city should be accepted.")
+
+	rng := newTestRand()
+	for i := 0; i < 6; i++ {
+		alien := &Alien{ID: i}
+		city, err := NoCollisionSpawnStrategy{}.SpawnCity(world, alien, rng)
+		assert.Nil(err)
+		assert.Empty(world.GetAliensInCity(city.Name), "Every alien should spawn in an empty city.")
+		world.AddAlienToCity(alien, city)
+	}
+	_, err := NoCollisionSpawnStrategy{}.SpawnCity(world, &Alien{ID: 6}, rng)
+	assert.IsType(&TooManyAliensError{}, err, "There should be no empty city left.")
+
+	world = createMovementTestWorld()
+	for i, cityName := range []string{"Hub", "North", "East", "South", "West"} {
+		spawnTestAlien(world, i, cityName)
+	}
+	world.AddAlienToCity(world.aliens[2], world.cities["Far"])
+	for i := 0; i < 10; i++ {
+		city, _ := NoCollisionSpawnStrategy{}.SpawnCity(world, &Alien{ID: 5}, rng)
+		assert.Equal("East", city.Name, "A city should be unoccupied again once its alien leaves.")
+	}
+	world.RemoveCity(world.cities["East"])
+	_, err = NoCollisionSpawnStrategy{}.SpawnCity(world, &Alien{ID: 5}, rng)
+	assert.IsType(&TooManyAliensError{}, err, "A destroyed city should not be unoccupied.")
+}
+
+func TestDegreeWeightedSpawnStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	rng := newTestRand()
+
+	picks := map[string]int{}
+	for i := 0; i < 5000; i++ {
+		city, err := DegreeWeightedSpawnStrategy{}.SpawnCity(world, &Alien{ID: i}, rng)
+		assert.Nil(err)
+		picks[city.Name]++
+	}
+	assert.InDelta(2000, picks["Hub"], 150, "A city with four of the ten roads should be picked 40% of the time.")
+	assert.InDelta(500, picks["North"], 100, "A city with one of the ten roads should be picked 10% of the time.")
+
+	world.RemoveCity(world.cities["Hub"])
+	picks = map[string]int{}
+	for i := 0; i < 200; i++ {
+		city, _ := DegreeWeightedSpawnStrategy{}.SpawnCity(world, &Alien{ID: i}, rng)
+		picks[city.Name]++
+	}
+	assert.Equal(2, len(picks), "Only the cities with roads left should be picked once Hub is destroyed.")
+	assert.Contains(picks, "East")
+	assert.Contains(picks, "Far")
+
+	world.RemoveRoad(world.cities["East"], world.cities["Far"])
+	picks = map[string]int{}
+	for i := 0; i < 200; i++ {
+		city, _ := DegreeWeightedSpawnStrategy{}.SpawnCity(world, &Alien{ID: i}, rng)
+		picks[city.Name]++
+	}
+	assert.Equal(5, len(picks), "Every city should be as likely once no road is left.")
+}
+
+func TestClusteredSpawnStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	assert.IsType(&NonExistentCityError{}, ClusteredSpawnStrategy{Center: "Nowhere"}.Validate(world, 1), "The center should exist.")
+
+	rng := newTestRand()
+	picks := map[string]int{}
+	for i := 0; i < 300; i++ {
+		city, err := ClusteredSpawnStrategy{Center: "East", Radius: 1}.SpawnCity(world, &Alien{ID: i}, rng)
+		assert.Nil(err)
+		picks[city.Name]++
+	}
+	assert.Equal(3, len(picks), "Only cities within a road of East should be picked.")
+	assert.Contains(picks, "Far")
+	assert.Contains(picks, "Hub")
+
+	city, _ := ClusteredSpawnStrategy{Center: "East", Radius: 0}.SpawnCity(world, &Alien{ID: 0}, rng)
+	assert.Equal("East", city.Name, "A radius of 0 should spawn every alien in the center.")
+
+	world.RemoveRoad(world.cities["East"], world.cities["Far"])
+	picks = map[string]int{}
+	for i := 0; i < 100; i++ {
+		city, _ := ClusteredSpawnStrategy{Center: "East", Radius: 1}.SpawnCity(world, &Alien{ID: i}, rng)
+		picks[city.Name]++
+	}
+	assert.Equal(2, len(picks), "A city should leave the cluster once its road is removed.")
+	assert.NotContains(picks, "Far")
+
+	world.RemoveCity(world.cities["East"])
+	city, err := ClusteredSpawnStrategy{Center: "East", Radius: 0}.SpawnCity(world, &Alien{ID: 0}, rng)
+	assert.Nil(err)
+	assert.NotNil(city, "Aliens should spawn anywhere once the center is destroyed.")
+}
+
+func TestPlacementSpawnStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	world := createMovementTestWorld()
+	strategy := PlacementSpawnStrategy{Placements: map[int]string{0: "Far", 1: "Hub"}}
+	assert.Nil(strategy.Validate(world, 2), "Every alien should be placed.")
+	assert.IsType(&InvalidPlacementError{}, strategy.Validate(world, 3), "An alien without a city should be rejected.")
+	assert.IsType(&InvalidPlacementError{}, strategy.Validate(world, 1), "A placement of an alien which does not spawn should be rejected.")
+	assert.IsType(&InvalidPlacementError{}, PlacementSpawnStrategy{Placements: map[int]string{0: "Nowhere"}}.Validate(world, 1), "A city which does not exist should be rejected.")
+
+	city, err := strategy.SpawnCity(world, &Alien{ID: 1}, newTestRand())
+	assert.Nil(err)
+	assert.Equal("Hub", city.Name, "The alien should spawn where it is placed.")
+
+	world.RemoveCity(world.cities["Far"])
+	_, err = strategy.SpawnCity(world, &Alien{ID: 0}, newTestRand())
+	assert.IsType(&InvalidPlacementError{}, err, "An alien placed in a destroyed city cannot spawn.")
+}
@@ -0,0 +1,53 @@
+package structs
+
+// weightTree holds a non-negative weight for every index along with their sums in a Fenwick tree,
+// so that a weight is changed and an index is picked in proportion to its weight in logarithmic time
+type weightTree struct {
+	weights []int
+
+	// sums[i] is the sum of the weights of the i & -i indexes up to and including i-1
+	sums  []int
+	total int
+}
+
+// createWeightTree builds the tree of the given weights in linear time
+func createWeightTree(weights []int) *weightTree {
+	tree := &weightTree{weights: append([]int{}, weights...), sums: make([]int, len(weights)+1)}
+	for i, weight := range weights {
+		tree.sums[i+1] += weight
+		if parent := i + 1 + (i+1)&-(i+1); parent <= len(weights) {
+			tree.sums[parent] += tree.sums[i+1]
+		}
+		tree.total += weight
+	}
+	return tree
+}
+
+// set changes the weight of the index
+func (tree *weightTree) set(index, weight int) {
+	delta := weight - tree.weights[index]
+	if delta == 0 {
+		return
+	}
+	tree.weights[index] = weight
+	tree.total += delta
+	for i := index + 1; i < len(tree.sums); i += i & -i {
+		tree.sums[i] += delta
+	}
+}
+
+// find returns the index at which the running sum of the weights exceeds pick, for 0 <= pick < total
+func (tree *weightTree) find(pick int) int {
+	step := 1
+	for step*2 < len(tree.sums) {
+		step *= 2
+	}
+	index := 0
+	for ; step > 0; step /= 2 {
+		if index+step < len(tree.sums) && tree.sums[index+step] <= pick {
+			index += step
+			pick -= tree.sums[index]
+		}
+	}
+	return index
+}
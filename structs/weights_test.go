@@ -0,0 +1,26 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightTree(t *testing.T) {
+	assert := assert.New(t)
+
+	tree := createWeightTree([]int{2, 0, 3, 1, 0, 4})
+	assert.Equal(10, tree.total, "The total should be the sum of the weights.")
+	expected := []int{0, 0, 2, 2, 2, 3, 5, 5, 5, 5}
+	for pick, index := range expected {
+		assert.Equal(index, tree.find(pick), "Pick %d should fall on index %d.", pick, index)
+	}
+
+	tree.set(2, 0)
+	tree.set(4, 2)
+	assert.Equal(9, tree.total, "The total should follow the changed weights.")
+	expected = []int{0, 0, 3, 4, 4, 5, 5, 5, 5}
+	for pick, index := range expected {
+		assert.Equal(index, tree.find(pick), "Pick %d should fall on index %d after the change.", pick, index)
+	}
+}
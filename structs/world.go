@@ -1,44 +1,114 @@
 package structs
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"math/rand"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/AleksandarHr/AlienInvasion/utils"
 	"github.com/phuslu/log"
 )
 
+// World holds the cities and the aliens. Cities are numbered in the order they are added to the world,
+// and both cities and aliens are stored in slices indexed by their IDs, so that spawning, moving and
+// killing an alien and destroying a city take constant time however large the world is
 type World struct {
-	// cities within the world
+	// cities within the world, keyed by name
 	cities map[string]*City
 
-	// aliens within the world
-	aliens map[int]*Alien
+	// every city added to the world, indexed by ID, nil once destroyed
+	citiesByID []*City
 
-	// aliens within the world who are untrapped (e.g. can travel to a neighboring city)
-	freeAliens map[int]*Alien
+	// cities which are still standing in no particular order, and the position of every city
+	// in it indexed by ID, so that a random city is picked and a city is removed in constant time
+	standingCities []*City
+	standingIndex  []int
 
-	// city names mapped to alien IDs
-	citiesAliens map[string]*Alien
+	// names of the cities which have been destroyed
+	destroyedCities map[string]bool
 
-	// map a city name to the names of all the cities it is linked to
-	cityConnections map[string]map[string]bool
+	// aliens within the world, indexed by ID, nil once dead, and the number of them still alive
+	aliens      []*Alien
+	aliveAliens int
+
+	// aliens within the world who are untrapped (e.g. can travel to a neighboring city), in no particular
+	// order, and the position of every alien in it indexed by ID, -1 if the alien is not free
+	freeAliens []*Alien
+	freeIndex  []int
+
+	// aliens in every city, indexed by city ID
+	citiesAliens [][]*Alien
+
+	// standing cities without aliens in no particular order, and the position of every city in it
+	// indexed by ID, -1 if the city has aliens or has been destroyed
+	unoccupiedCities []*City
+	unoccupiedIndex  []int
+
+	// number of roads leading out of every city indexed by ID, so that a city is picked in proportion
+	// to its roads in logarithmic time. It is built when first needed, and follows the roads removed since
+	roadWeights *weightTree
+
+	// cities within the radius of a center city, found after the given number of road changes
+	clusters    map[clusterKey]*cluster
+	roadChanges int
+
+	// IDs of all the cities every city is linked to, indexed by city ID
+	cityConnections [][]int
+
+	// receives the events taking place in the world, if set
+	recorder EventRecorder
+
+	// decides the outcome of fights, drawing from fightRand
+	resolver  FightResolver
+	fightRand *rand.Rand
 }
 
 // CreateWorld construct a new world
 func CreateWorld() *World {
 	return &World{
 		cities:          make(map[string]*City),
-		aliens:          make(map[int]*Alien),
-		freeAliens:      make(map[int]*Alien),
-		cityConnections: make(map[string]map[string]bool),
-		citiesAliens:    make(map[string]*Alien),
+		destroyedCities: make(map[string]bool),
+		resolver:        MutualDestructionResolver{},
 	}
 }
 
-// InitializeWorld creates the world based on information from the map file
+// SetFightResolver sets the resolver deciding the outcome of fights and the random source it draws from
+func (w *World) SetFightResolver(resolver FightResolver, rng *rand.Rand) {
+	w.resolver = resolver
+	w.fightRand = rng
+}
+
+// FightResolver returns the resolver deciding the outcome of fights
+func (w *World) FightResolver() FightResolver {
+	return w.resolver
+}
+
+// SetEventRecorder sets the recorder receiving the events taking place in the world
+func (w *World) SetEventRecorder(recorder EventRecorder) {
+	w.recorder = recorder
+}
+
+// recordEvent passes the event to the event recorder, if one is set
+func (w *World) recordEvent(event Event) {
+	if w.recorder != nil {
+		w.recorder.RecordEvent(event)
+	}
+}
+
+// InitializeWorld creates the world based on information from the map file. Cities are added
+// in the order of their names, so that a map always numbers its cities the same way
 func (w *World) InitializeWorld(mapInfo map[string][]string) {
+	cityNames := make([]string, 0, len(mapInfo))
 	for cityName := range mapInfo {
+		cityNames = append(cityNames, cityName)
+	}
+	sort.Strings(cityNames)
+
+	for _, cityName := range cityNames {
 		// add a city
 		newCity := CreateCity(cityName)
 		err := w.AddNewCity(newCity)
@@ -48,10 +118,14 @@ func (w *World) InitializeWorld(mapInfo map[string][]string) {
 		}
 	}
 
-	for cityName, neighbourNames := range mapInfo {
+	for _, cityName := range cityNames {
 		// add it's neighbours and links
-		for _, neighbourInfo := range neighbourNames {
+		for _, neighbourInfo := range mapInfo[cityName] {
 			temp := strings.Split(neighbourInfo, "=")
+			if len(temp) != 2 {
+				// malformed road, should NOT happen for maps which pass validation
+				continue
+			}
 			neighbourDirection, neighbourName := temp[0], temp[1]
 			if _, exists := w.cities[neighbourName]; !exists {
 				// should NOT happen based on map.txt contents assumption
@@ -69,34 +143,51 @@ func (w *World) InitializeWorld(mapInfo map[string][]string) {
 			newCity.Neighbours[StringToDirection(neighbourDirection)] = neighbourCity
 
 			// add relevant links information
-			w.addLinks(cityName, neighbourName)
+			w.addLinks(newCity, neighbourCity)
 		}
 	}
 }
 
-// AddNewCity creates a new city with the specified name and adds it to the world
+// AddNewCity adds the city to the world, giving it the next city ID
 func (w *World) AddNewCity(newCity *City) error {
 	if newCity == nil {
 		return &InvalidCityError{city: newCity}
 	}
 	// if city has already been added to the world
 	if _, exists := w.cities[newCity.Name]; !exists {
+		newCity.ID = len(w.citiesByID)
 		w.cities[newCity.Name] = newCity
+		w.citiesByID = append(w.citiesByID, newCity)
+		w.standingIndex = append(w.standingIndex, len(w.standingCities))
+		w.standingCities = append(w.standingCities, newCity)
+		w.citiesAliens = append(w.citiesAliens, nil)
+		w.cityConnections = append(w.cityConnections, nil)
+		w.unoccupiedIndex = append(w.unoccupiedIndex, -1)
+		w.addUnoccupied(newCity)
+		w.roadWeights = nil
 	}
 	return nil
 }
 
+// hasCity checks if the city is standing in the world
+func (w *World) hasCity(city *City) bool {
+	return city.ID >= 0 && city.ID < len(w.citiesByID) && w.citiesByID[city.ID] == city
+}
+
 // addLinks populates informatino about links between a given pair of cities
-func (w *World) addLinks(cityOneName, cityTwoName string) {
-	if _, exists := w.cityConnections[cityOneName]; !exists {
-		w.cityConnections[cityOneName] = make(map[string]bool)
-	}
-	w.cityConnections[cityOneName][cityTwoName] = true
+func (w *World) addLinks(cityOne, cityTwo *City) {
+	w.addConnection(cityOne.ID, cityTwo.ID)
+	w.addConnection(cityTwo.ID, cityOne.ID)
+}
 
-	if _, exists := w.cityConnections[cityTwoName]; !exists {
-		w.cityConnections[cityTwoName] = make(map[string]bool)
+// addConnection links the city with the given ID to the other city, unless they are linked already
+func (w *World) addConnection(cityID, otherID int) {
+	for _, connection := range w.cityConnections[cityID] {
+		if connection == otherID {
+			return
+		}
 	}
-	w.cityConnections[cityTwoName][cityOneName] = true
+	w.cityConnections[cityID] = append(w.cityConnections[cityID], otherID)
 }
 
 // RemoveCity removes the given city and any related information about it from the world
@@ -106,118 +197,401 @@ func (w *World) RemoveCity(cityToRemove *City) error {
 	}
 
 	cityNameToRemove := cityToRemove.Name
-	if _, exists := w.cities[cityNameToRemove]; !exists {
+	if !w.hasCity(cityToRemove) {
 		return &NonExistentCityError{cityName: cityNameToRemove}
 	}
 
-	// delete city from world map
-	if _, ok := w.cities[cityNameToRemove]; ok {
-		delete(w.cities, cityNameToRemove)
+	// delete city from world map, moving the last standing city into its place
+	delete(w.cities, cityNameToRemove)
+	w.citiesByID[cityToRemove.ID] = nil
+	index := w.standingIndex[cityToRemove.ID]
+	last := w.standingCities[len(w.standingCities)-1]
+	w.standingCities[index] = last
+	w.standingIndex[last.ID] = index
+	w.standingCities = w.standingCities[:len(w.standingCities)-1]
+	w.standingIndex[cityToRemove.ID] = -1
+	w.removeUnoccupied(cityToRemove)
+	w.setRoadWeight(cityToRemove)
+	w.roadChanges++
+
+	// delete relevant connections to this city, in a stable order
+	connections := make([]*City, 0, len(w.cityConnections[cityToRemove.ID]))
+	for _, connectionID := range w.cityConnections[cityToRemove.ID] {
+		connections = append(connections, w.citiesByID[connectionID])
+	}
+	sort.Slice(connections, func(i, j int) bool { return connections[i].Name < connections[j].Name })
+	for _, connection := range connections {
+		w.removeConnection(connection, cityToRemove)
+	}
+	w.cityConnections[cityToRemove.ID] = nil
+
+	// aliens still in the city die along with it
+	for _, alien := range w.aliensIn(cityToRemove) {
+		w.killAlien(alien)
 	}
+	w.citiesAliens[cityToRemove.ID] = nil
+	w.destroyedCities[cityNameToRemove] = true
 
-	// delete relevant connections to this city
-	if connectedCities, exists := w.cityConnections[cityNameToRemove]; exists {
-		for connection, _ := range connectedCities {
-			w.removeConnection(connection, cityNameToRemove)
-		}
-	}
+	w.recordEvent(Event{Type: CityDestroyed, City: cityNameToRemove})
+	return nil
+}
 
-	if _, ok := w.cityConnections[cityNameToRemove]; ok {
-		delete(w.cityConnections, cityNameToRemove)
+// RemoveRoad removes the road between the two cities, in both directions
+func (w *World) RemoveRoad(cityOne, cityTwo *City) error {
+	if cityOne == nil {
+		return &InvalidCityError{city: cityOne}
 	}
-
-	// remove mapping from city to alien in the city
-	if _, ok := w.citiesAliens[cityNameToRemove]; ok {
-		delete(w.citiesAliens, cityNameToRemove)
+	if cityTwo == nil {
+		return &InvalidCityError{city: cityTwo}
 	}
 
-	return nil
+	if err := w.removeConnection(cityOne, cityTwo); err != nil {
+		return err
+	}
+	return w.removeConnection(cityTwo, cityOne)
 }
 
 // removeConnection deletes information about connection between the two cities
-func (w *World) removeConnection(connection, cityNameToRemove string) error {
-	if _, exists := w.cities[connection]; !exists {
-		return &NonExistentCityError{cityName: connection}
+func (w *World) removeConnection(connectionCity, cityToRemove *City) error {
+	if !w.hasCity(connectionCity) {
+		return &NonExistentCityError{cityName: connectionCity.Name}
 	}
-	connectionCity := w.cities[connection]
 
-	if connectionCity.Neighbours[North] != nil && connectionCity.Neighbours[North].Name == cityNameToRemove {
-		delete(connectionCity.Neighbours, North)
-	} else if connectionCity.Neighbours[East] != nil && connectionCity.Neighbours[East].Name == cityNameToRemove {
-		delete(connectionCity.Neighbours, East)
-	} else if connectionCity.Neighbours[South] != nil && connectionCity.Neighbours[South].Name == cityNameToRemove {
-		delete(connectionCity.Neighbours, South)
-	} else if connectionCity.Neighbours[West] != nil && connectionCity.Neighbours[West].Name == cityNameToRemove {
-		delete(connectionCity.Neighbours, West)
+	for _, dir := range []Direction{North, East, South, West} {
+		if connectionCity.Neighbours[dir] != nil && connectionCity.Neighbours[dir].Name == cityToRemove.Name {
+			delete(connectionCity.Neighbours, dir)
+			w.setRoadWeight(connectionCity)
+			w.recordEvent(Event{Type: RoadRemoved, City: cityToRemove.Name, From: connectionCity.Name, Direction: dir.MapKeyword()})
+			break
+		}
 	}
 
-	if connections, exists := w.cityConnections[connection]; exists {
-		if _, exists = connections[cityNameToRemove]; exists {
-			delete(w.cityConnections[connection], cityNameToRemove)
+	w.roadChanges++
+	connections := w.cityConnections[connectionCity.ID]
+	for i, connectionID := range connections {
+		if connectionID == cityToRemove.ID {
+			connections[i] = connections[len(connections)-1]
+			w.cityConnections[connectionCity.ID] = connections[:len(connections)-1]
+			break
 		}
 	}
-	// if the connectionCity is left with no connections, update freeAliens map if necessary
+	// if the connectionCity is left with no connections, update freeAliens if necessary
 	if !connectionCity.HasNeighbours() {
-		if alien, exists := w.citiesAliens[connectionCity.Name]; exists {
-			delete(w.freeAliens, alien.ID)
+		for _, alien := range w.aliensIn(connectionCity) {
+			if w.trapAlien(alien) {
+				w.recordEvent(Event{Type: AlienTrapped, AlienIDs: []int{alien.ID}, AlienNames: []string{alien.Name}, City: connectionCity.Name})
+			}
 		}
 	}
 	return nil
 }
 
+// addUnoccupied adds the city to the unoccupied cities, unless it is destroyed or listed already
+func (w *World) addUnoccupied(city *City) {
+	if !w.hasCity(city) || w.unoccupiedIndex[city.ID] >= 0 {
+		return
+	}
+	w.unoccupiedIndex[city.ID] = len(w.unoccupiedCities)
+	w.unoccupiedCities = append(w.unoccupiedCities, city)
+}
+
+// removeUnoccupied removes the city from the unoccupied cities, moving the last of them into its place
+func (w *World) removeUnoccupied(city *City) {
+	index := w.unoccupiedIndex[city.ID]
+	if index < 0 {
+		return
+	}
+	last := w.unoccupiedCities[len(w.unoccupiedCities)-1]
+	w.unoccupiedCities[index] = last
+	w.unoccupiedIndex[last.ID] = index
+	w.unoccupiedCities = w.unoccupiedCities[:len(w.unoccupiedCities)-1]
+	w.unoccupiedIndex[city.ID] = -1
+}
+
+// setRoadWeight updates the number of roads leading out of the city, none once it is destroyed,
+// if the road weights have been built
+func (w *World) setRoadWeight(city *City) {
+	if w.roadWeights == nil {
+		return
+	}
+	weight := 0
+	if w.hasCity(city) {
+		weight = len(city.neighbourCities())
+	}
+	w.roadWeights.set(city.ID, weight)
+}
+
 // killAlien destroys the given alien by removing references from relevant variables
 func (w *World) killAlien(alien *Alien) error {
 	if alien == nil {
 		return &InvalidAlienError{alien: alien}
 	}
 
-	if _, exists := w.aliens[alien.ID]; exists {
-		delete(w.aliens, alien.ID)
-	}
-	if _, exists := w.freeAliens[alien.ID]; exists {
-		delete(w.freeAliens, alien.ID)
+	if alien.ID >= 0 && alien.ID < len(w.aliens) && w.aliens[alien.ID] != nil {
+		w.trapAlien(alien)
+		w.aliens[alien.ID] = nil
+		w.aliveAliens--
 	}
 	return nil
 }
 
-// AddNewAlienToCity attempts to add the given alien to the specified city.
-// If the city already has an alien in it, the move is unsuccessful
-func (w *World) AddAlienToCity(alien *Alien, to *City, stage SimulationStage) (bool, error) {
-	if alien == nil {
+// addAlien stores the alien by its ID, making room for it if its ID is the highest so far
+func (w *World) addAlien(alien *Alien) {
+	for len(w.aliens) <= alien.ID {
+		w.aliens = append(w.aliens, nil)
+		w.freeIndex = append(w.freeIndex, -1)
+	}
+	if w.aliens[alien.ID] == nil {
+		w.aliveAliens++
+	}
+	w.aliens[alien.ID] = alien
+}
+
+// freeAlien adds the alien to the free aliens, unless it is free already
+func (w *World) freeAlien(alien *Alien) {
+	if w.freeIndex[alien.ID] >= 0 {
+		return
+	}
+	w.freeIndex[alien.ID] = len(w.freeAliens)
+	w.freeAliens = append(w.freeAliens, alien)
+}
+
+// trapAlien removes the alien from the free aliens, moving the last free alien into its place,
+// and returns whether the alien was free
+func (w *World) trapAlien(alien *Alien) bool {
+	if alien.ID < 0 || alien.ID >= len(w.freeIndex) || w.freeIndex[alien.ID] < 0 {
+		return false
+	}
+	index := w.freeIndex[alien.ID]
+	last := w.freeAliens[len(w.freeAliens)-1]
+	w.freeAliens[index] = last
+	w.freeIndex[last.ID] = index
+	w.freeAliens = w.freeAliens[:len(w.freeAliens)-1]
+	w.freeIndex[alien.ID] = -1
+	return true
+}
+
+// AddAlienToCity attempts to add the given alien to the specified city, spawning it there if it has no location yet.
+// If the city already has aliens in it, the fight resolver decides whether they fight,
+// in which case the move is successful only if the arriving alien survives
+func (w *World) AddAlienToCity(alien *Alien, to *City) (bool, error) {
+	if alien == nil || alien.ID < 0 {
 		return false, &InvalidAlienError{alien: alien}
 	}
 	if to == nil {
 		return false, &InvalidCityError{city: to}
 	}
-	if _, ok := w.cities[to.Name]; !ok {
+	if !w.hasCity(to) {
 		return false, &NonExistentCityError{cityName: to.Name}
 	}
 
-	// If the alien is already present in a different city, update cities-to-aliens map
-	if stage != SpawningAliens {
-		if _, exists := w.citiesAliens[alien.Location.Name]; exists {
-			delete(w.citiesAliens, alien.Location.Name)
+	// If the alien is already present in a different city, update cities-to-aliens information.
+	// A newly spawned alien has no location, whether it spawns before or during the iterations
+	if alien.Location != nil {
+		w.removeAlienFromCity(alien, alien.Location)
+	}
+
+	// if the city already has aliens there, they may fight
+	if len(w.citiesAliens[to.ID]) > 0 {
+		survivors, err := w.fightInCity(to, w.aliensIn(to), []*Alien{alien})
+		return len(survivors) == 1, err
+	}
+
+	// there is not alien in the origin city, spawn the new alien there
+	_ = w.updateAlienLocation(alien, to)
+	return true, nil
+}
+
+// AlienMove holds the city an alien moves to
+type AlienMove struct {
+	Alien *Alien
+	To    *City
+}
+
+// MoveAliensSimultaneously moves every alien to its destination at once. Aliens travelling along
+// the same road in opposite directions meet on the road, where the fight resolver decides whether
+// they fight; a destroyed road is removed. The aliens which make it to their destination then
+// arrive together, and fight with each other and with the aliens staying in the city
+func (w *World) MoveAliensSimultaneously(moves []AlienMove) error {
+	for _, move := range moves {
+		if move.Alien == nil {
+			return &InvalidAlienError{alien: move.Alien}
+		}
+		if move.To == nil {
+			return &InvalidCityError{city: move.To}
+		}
+		if !w.hasCity(move.To) {
+			return &NonExistentCityError{cityName: move.To.Name}
+		}
+		if alive, _ := w.IsAlienAlive(move.Alien); !alive {
+			return &NonExistentAlienError{alienID: move.Alien.ID}
 		}
 	}
 
-	// if the city already has an alien there
-	if existingAlien, hasAlien := w.citiesAliens[to.Name]; hasAlien {
+	// group the moves by road, whose ends are ordered by ID
+	roads := make(map[[2]int][]AlienMove)
+	for _, move := range moves {
+		road := [2]int{move.Alien.Location.ID, move.To.ID}
+		if road[1] < road[0] {
+			road[0], road[1] = road[1], road[0]
+		}
+		roads[road] = append(roads[road], move)
+	}
+	roadIDs := make([][2]int, 0, len(roads))
+	for road := range roads {
+		roadIDs = append(roadIDs, road)
+	}
+	sort.Slice(roadIDs, func(i, j int) bool {
+		return roadIDs[i][0] < roadIDs[j][0] || (roadIDs[i][0] == roadIDs[j][0] && roadIDs[i][1] < roadIDs[j][1])
+	})
+
+	// aliens meeting on a road fight there, and only the survivors travel on
+	travelling := []AlienMove{}
+	for _, road := range roadIDs {
+		roadMoves := roads[road]
+		meeting := false
+		for _, move := range roadMoves {
+			meeting = meeting || move.To != roadMoves[0].To
+		}
+		if !meeting {
+			travelling = append(travelling, roadMoves...)
+			continue
+		}
 
-		// kill existing alien
-		w.killAlien(existingAlien)
-		// kill new alien
-		w.killAlien(alien)
+		aliens := []*Alien{}
+		for _, move := range roadMoves {
+			aliens = append(aliens, move.Alien)
+		}
+		sort.Slice(aliens, func(i, j int) bool { return aliens[i].ID < aliens[j].ID })
+		survivors, err := w.fightOnRoad(w.citiesByID[road[0]], w.citiesByID[road[1]], aliens)
+		if err != nil {
+			return err
+		}
+		for _, move := range roadMoves {
+			for _, survivor := range survivors {
+				if survivor.ID == move.Alien.ID {
+					travelling = append(travelling, move)
+				}
+			}
+		}
+	}
 
-		// destroy city
-		w.RemoveCity(to)
+	// every travelling alien leaves its city before any of them arrives
+	arrivals := make(map[int][]*Alien)
+	for _, move := range travelling {
+		w.removeAlienFromCity(move.Alien, move.Alien.Location)
+		arrivals[move.To.ID] = append(arrivals[move.To.ID], move.Alien)
+	}
+	destinations := make([]int, 0, len(arrivals))
+	for destination := range arrivals {
+		destinations = append(destinations, destination)
+	}
+	sort.Ints(destinations)
 
-		fmt.Printf("%s has been destroyed by alien %d and alien %d\n", to.Name, existingAlien.ID, alien.ID)
-		return false, nil
+	for _, destination := range destinations {
+		city := w.citiesByID[destination]
+		arriving := arrivals[destination]
+		sort.Slice(arriving, func(i, j int) bool { return arriving[i].ID < arriving[j].ID })
+
+		existingAliens := w.aliensIn(city)
+		if len(existingAliens) == 0 && len(arriving) == 1 {
+			_ = w.updateAlienLocation(arriving[0], city)
+			continue
+		}
+		if _, err := w.fightInCity(city, existingAliens, arriving); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// there is not alien in the origin city, spawn the new alien there
-	_ = w.updateAlienLocation(alien, to)
-	return true, nil
+// fightInCity resolves a fight between the aliens in the city and the arriving aliens. The arriving
+// aliens which survive, or all of them if there is no fight, are moved into the city and returned
+func (w *World) fightInCity(city *City, existingAliens, arrivingAliens []*Alien) ([]*Alien, error) {
+	aliens := append(append([]*Alien{}, existingAliens...), arrivingAliens...)
+	outcome, err := w.resolveFight(aliens, city, nil)
+	if err != nil {
+		return nil, err
+	}
+	if outcome != nil && outcome.CityDestroyed {
+		w.RemoveCity(city)
+		return nil, nil
+	}
+
+	arrived := []*Alien{}
+	for _, alien := range arrivingAliens {
+		if outcome == nil || containsAlien(outcome.Survivors, alien) {
+			_ = w.updateAlienLocation(alien, city)
+			arrived = append(arrived, alien)
+		}
+	}
+	return arrived, nil
+}
+
+// fightOnRoad resolves a fight between aliens meeting on the road between the two cities, removing the
+// road if it is destroyed. It returns the aliens which survive, or all of them if there is no fight
+func (w *World) fightOnRoad(from, to *City, aliens []*Alien) ([]*Alien, error) {
+	outcome, err := w.resolveFight(aliens, to, from)
+	if err != nil {
+		return nil, err
+	}
+	if outcome == nil {
+		return aliens, nil
+	}
+	if outcome.CityDestroyed {
+		w.RemoveRoad(from, to)
+	}
+	return outcome.Survivors, nil
+}
+
+// resolveFight lets the resolver decide the outcome of a fight between the aliens, records the fight
+// and kills the aliens which do not survive it. The fight takes place in the city, or on the road
+// between from and the city if from is set, in which case a destroyed city stands for a destroyed road.
+// It returns the outcome, or nil if the aliens do not fight
+func (w *World) resolveFight(aliens []*Alien, city, from *City) (*FightOutcome, error) {
+	outcome, err := w.resolver.Resolve(city, aliens, w.fightRand)
+	if err != nil || outcome == nil {
+		return nil, err
+	}
+
+	// nobody survives in a destroyed city
+	if outcome.CityDestroyed {
+		outcome = &FightOutcome{CityDestroyed: true}
+	}
+
+	fight := Event{Type: FightOccurred, City: city.Name, CitySurvived: !outcome.CityDestroyed, Resolver: w.resolver.String()}
+	if from != nil {
+		fight.From = from.Name
+		fight.Direction = from.DirectionTo(city).MapKeyword()
+	}
+	for _, alien := range aliens {
+		fight.AlienIDs = append(fight.AlienIDs, alien.ID)
+		fight.AlienNames = append(fight.AlienNames, alien.Name)
+		if containsAlien(outcome.Survivors, alien) {
+			fight.Survivors = append(fight.Survivors, alien.ID)
+		}
+	}
+	w.recordEvent(fight)
+
+	for _, alien := range aliens {
+		if !containsAlien(outcome.Survivors, alien) {
+			w.killAlien(alien)
+			if alien.Location != nil {
+				w.removeAlienFromCity(alien, alien.Location)
+			}
+		}
+	}
+	return outcome, nil
+}
+
+// containsAlien checks if the alien is among the given aliens
+func containsAlien(aliens []*Alien, alien *Alien) bool {
+	for _, other := range aliens {
+		if other.ID == alien.ID {
+			return true
+		}
+	}
+	return false
 }
 
 // updateAlienLocation moves an alien to a new city and updates relevant information
@@ -233,30 +607,95 @@ func (w *World) updateAlienLocation(alien *Alien, newCity *City) error {
 	alien.MoveToCity(newCity)
 
 	// update relevant variables
-	w.aliens[alien.ID] = alien
-	w.citiesAliens[newCity.Name] = alien
+	w.addAlien(alien)
+	w.citiesAliens[newCity.ID] = append(w.citiesAliens[newCity.ID], alien)
+	w.removeUnoccupied(newCity)
 	if newCity.HasNeighbours() {
-		w.freeAliens[alien.ID] = alien
+		w.freeAlien(alien)
+	} else {
+		w.trapAlien(alien)
+		w.recordEvent(Event{Type: AlienTrapped, AlienIDs: []int{alien.ID}, AlienNames: []string{alien.Name}, City: newCity.Name})
 	}
 	return nil
 }
 
-// GetAllCities returns all currentlt existing cities
+// GetCity returns the city with the given name, if it exists
+func (w *World) GetCity(cityName string) (*City, bool) {
+	city, exists := w.cities[cityName]
+	return city, exists
+}
+
+// GetAllCities returns all currentlt existing cities, ordered by name
 func (w *World) GetAllCities() ([]*City, error) {
-	cities := []*City{}
-	for _, city := range w.cities {
-		cities = append(cities, city)
-	}
+	cities := append([]*City{}, w.standingCities...)
+	sort.Slice(cities, func(i, j int) bool { return cities[i].Name < cities[j].Name })
 	return cities, nil
 }
 
-// GetFreeAliens returns all aliens which are not trapped
+// GetRandomCity returns a city chosen uniformly at random using the provided random source,
+// or nil if there are no cities left
+func (w *World) GetRandomCity(rng *rand.Rand) (*City, error) {
+	if len(w.standingCities) == 0 {
+		return nil, nil
+	}
+
+	randCityIdx, err := utils.GenerateRandomNumber(rng, len(w.standingCities))
+	if err != nil {
+		return nil, err
+	}
+	return w.standingCities[randCityIdx], nil
+}
+
+// GetFreeAliens returns all aliens which are not trapped. They are not ordered, though the
+// order only depends on what happened in the world and is kept by a snapshot of it
 func (w *World) GetFreeAliens() ([]*Alien, error) {
-	currentFreeAliens := []*Alien{}
-	for _, alien := range w.freeAliens {
-		currentFreeAliens = append(currentFreeAliens, alien)
+	return append([]*Alien{}, w.freeAliens...), nil
+}
+
+// GetAllAliens returns all aliens which are still alive, ordered by ID
+func (w *World) GetAllAliens() ([]*Alien, error) {
+	aliveAliens := make([]*Alien, 0, w.aliveAliens)
+	for _, alien := range w.aliens {
+		if alien != nil {
+			aliveAliens = append(aliveAliens, alien)
+		}
+	}
+	return aliveAliens, nil
+}
+
+// GetAliensInCity returns the aliens currently in the city with the given name, ordered by ID
+func (w *World) GetAliensInCity(cityName string) []*Alien {
+	city, exists := w.cities[cityName]
+	if !exists {
+		return []*Alien{}
+	}
+	return w.aliensIn(city)
+}
+
+// aliensIn returns the aliens currently in the city, ordered by ID
+func (w *World) aliensIn(city *City) []*Alien {
+	aliens := append([]*Alien{}, w.citiesAliens[city.ID]...)
+	sort.Slice(aliens, func(i, j int) bool { return aliens[i].ID < aliens[j].ID })
+	return aliens
+}
+
+// removeAlienFromCity removes the alien from the aliens in the city, moving the last of them into its place
+func (w *World) removeAlienFromCity(alien *Alien, city *City) {
+	if !w.hasCity(city) {
+		return
+	}
+	aliens := w.citiesAliens[city.ID]
+	for i, other := range aliens {
+		if other.ID == alien.ID {
+			aliens[i] = aliens[len(aliens)-1]
+			aliens[len(aliens)-1] = nil
+			w.citiesAliens[city.ID] = aliens[:len(aliens)-1]
+			if len(aliens) == 1 {
+				w.addUnoccupied(city)
+			}
+			return
+		}
 	}
-	return currentFreeAliens, nil
 }
 
 // IsAlienFree checks if a given alien is still free
@@ -264,11 +703,7 @@ func (w *World) IsAlienFree(alien *Alien) (bool, error) {
 	if alien == nil {
 		return false, &InvalidAlienError{alien: alien}
 	}
-
-	if _, exists := w.freeAliens[alien.ID]; exists {
-		return true, nil
-	}
-	return false, nil
+	return alien.ID >= 0 && alien.ID < len(w.freeIndex) && w.freeIndex[alien.ID] >= 0, nil
 }
 
 // IsAlienAlive checks if a given alien is still alive
@@ -276,16 +711,27 @@ func (w *World) IsAlienAlive(alien *Alien) (bool, error) {
 	if alien == nil {
 		return false, &InvalidAlienError{alien: alien}
 	}
-
-	if _, exists := w.aliens[alien.ID]; exists {
-		return true, nil
-	}
-	return false, nil
+	return alien.ID >= 0 && alien.ID < len(w.aliens) && w.aliens[alien.ID] != nil, nil
 }
 
 // AllAliensDead checks if all aliens have died
 func (w *World) AllAliensDead() bool {
-	return len(w.aliens) == 0
+	return w.aliveAliens == 0
+}
+
+// GetDestroyedCities returns the names of the destroyed cities, sorted by name
+func (w *World) GetDestroyedCities() []string {
+	cityNames := make([]string, 0, len(w.destroyedCities))
+	for cityName := range w.destroyedCities {
+		cityNames = append(cityNames, cityName)
+	}
+	sort.Strings(cityNames)
+	return cityNames
+}
+
+// AllCitiesDestroyed checks if all cities have been destroyed
+func (w *World) AllCitiesDestroyed() bool {
+	return len(w.cities) == 0
 }
 
 // AllAliensTrapped checks if all remaining aliens are trapped
@@ -293,10 +739,41 @@ func (w *World) AllAliensTrapped() bool {
 	return len(w.freeAliens) == 0
 }
 
+// WriteMap writes the remaining cities and roads in the map file format,
+// one line per city with its neighbours in north, east, south, west order
+func (w *World) WriteMap(out io.Writer) error {
+	cityNames := make([]string, 0, len(w.cities))
+	for cityName := range w.cities {
+		cityNames = append(cityNames, cityName)
+	}
+	sort.Strings(cityNames)
+
+	writer := bufio.NewWriter(out)
+	for _, cityName := range cityNames {
+		var line strings.Builder
+		line.WriteString(cityName)
+		city := w.cities[cityName]
+		for _, dir := range []Direction{North, East, South, West} {
+			if neighbour := city.Neighbours[dir]; neighbour != nil {
+				line.WriteString(" " + dir.MapKeyword() + "=" + neighbour.Name)
+			}
+		}
+		if _, err := writer.WriteString(line.String() + "\n"); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
 // =========================================================================================
 // Print Helpers
 // =========================================================================================
+// LogWorldState logs the cities, their connections and the aliens. It goes through the whole world,
+// so it returns at once unless the logger logs debug messages
 func (w *World) LogWorldState(debugLogger log.Logger) {
+	if debugLogger.Level > log.DebugLevel {
+		return
+	}
 	w.printCitiesTopology(debugLogger)
 	w.printCitiesConnections(debugLogger)
 	w.printExistingCities(debugLogger)
@@ -321,11 +798,11 @@ func (w *World) printCitiesTopology(debugLogger log.Logger) {
 
 // printCitiesConnections prints information about each cities connections
 func (w *World) printCitiesConnections(debugLogger log.Logger) {
-	for cityName, connectionCities := range w.cityConnections {
+	for _, city := range w.standingCities {
 		var connections strings.Builder
-		connections.WriteString(cityName + " is connected to: ")
-		for connection, _ := range connectionCities {
-			connections.WriteString(connection + ", ")
+		connections.WriteString(city.Name + " is connected to: ")
+		for _, connectionID := range w.cityConnections[city.ID] {
+			connections.WriteString(w.citiesByID[connectionID].Name + ", ")
 		}
 		debugLogger.Debug().Msgf("Connections: %s", connections.String())
 	}
@@ -335,7 +812,10 @@ func (w *World) printCitiesConnections(debugLogger log.Logger) {
 func (w *World) printAliensInfo(debugLogger log.Logger) {
 	var aliens strings.Builder
 	for id, alien := range w.aliens {
-		if _, isFree := w.freeAliens[alien.ID]; isFree {
+		if alien == nil {
+			continue
+		}
+		if free, _ := w.IsAlienFree(alien); free {
 			aliens.WriteString("Alien " + strconv.Itoa(id) + " is in " + alien.Location.Name + ", ")
 		} else {
 			aliens.WriteString("Alien " + strconv.Itoa(id) + " is TRAPPED in " + alien.Location.Name + ", ")
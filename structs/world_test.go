@@ -1,9 +1,14 @@
 package structs
 
 import (
+	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/AleksandarHr/AlienInvasion/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -68,34 +73,35 @@ func TestInitializeWorld(t *testing.T) {
 	assert.Contains(world.cities, "Qu-ux", "Qu-ux should exist in the cities map.")
 	assert.Equal(len(world.aliens), 0, "The number of aliens should be 0.")
 
-	// cities added to world.cityConnections
-	assert.Equal(len(world.cityConnections), 5, "The city connections map should have 5 entries.")
-	assert.Contains(world.cityConnections, "Foo", "Foo should exist in the cityConnections map.")
-	fooConnections := world.cityConnections["Foo"]
+	// cities linked to each other, as listed by a snapshot of the world
+	cityConnections := world.Snapshot().CityConnections
+	assert.Equal(len(cityConnections), 5, "The city connections map should have 5 entries.")
+	assert.Contains(cityConnections, "Foo", "Foo should exist in the cityConnections map.")
+	fooConnections := cityConnections["Foo"]
 	assert.Equal(len(fooConnections), 3, "Foo shuold have 3 connections.")
 	assert.Contains(fooConnections, "Bar", "Bar should be a connection of Foo.")
 	assert.Contains(fooConnections, "Baz", "Baz should be a connection of Foo.")
 	assert.Contains(fooConnections, "Qu-ux", "Qu-ux should be a connection of Foo.")
 
 	// citiyConnections contain the correct information
-	assert.Contains(world.cityConnections, "Bar", "Bar should exist in the cityConnections map.")
-	barConnections := world.cityConnections["Bar"]
+	assert.Contains(cityConnections, "Bar", "Bar should exist in the cityConnections map.")
+	barConnections := cityConnections["Bar"]
 	assert.Equal(len(barConnections), 2, "Bar shuold have 2 connections.")
 	assert.Contains(barConnections, "Foo", "Foo should be a connection of Bar.")
 	assert.Contains(barConnections, "Bee", "Bee should be a connection of Bar.")
 
-	assert.Contains(world.cityConnections, "Baz", "Baz should exist in the cityConnections map.")
-	bazConnections := world.cityConnections["Baz"]
+	assert.Contains(cityConnections, "Baz", "Baz should exist in the cityConnections map.")
+	bazConnections := cityConnections["Baz"]
 	assert.Equal(len(bazConnections), 1, "Baz shuold have 1 connection.")
 	assert.Contains(bazConnections, "Foo", "Foo should be a connection of Baz.")
 
-	assert.Contains(world.cityConnections, "Qu-ux", "Qu-ux should exist in the cityConnections map.")
-	quuxConnections := world.cityConnections["Qu-ux"]
+	assert.Contains(cityConnections, "Qu-ux", "Qu-ux should exist in the cityConnections map.")
+	quuxConnections := cityConnections["Qu-ux"]
 	assert.Equal(len(quuxConnections), 1, "Qu-ux shuold have 1 connections.")
 	assert.Contains(quuxConnections, "Foo", "Foo should be a connection of Qu-ux.")
 
-	assert.Contains(world.cityConnections, "Bee", "Bee should exist in the cityConnections map.")
-	beeConnections := world.cityConnections["Bee"]
+	assert.Contains(cityConnections, "Bee", "Bee should exist in the cityConnections map.")
+	beeConnections := cityConnections["Bee"]
 	assert.Equal(len(beeConnections), 1, "Bee shuold have 1 connections.")
 	assert.Contains(beeConnections, "Bar", "Bar should be a connection of Bee.")
 
@@ -153,16 +159,17 @@ func TestRemoveCity(t *testing.T) {
 	assert.Contains(world.cities, "Bee", "Bar should exist in the cities map.")
 	assert.NotContains(world.cities, "Foo", "Foo should not exist in the cities map.")
 
-	assert.Equal(len(world.cityConnections), 2, "The city connections map should have 2 entries.")
-	assert.NotContains(world.cityConnections, "Foo", "Foo should not exist in the cityConnections map.")
+	cityConnections := world.Snapshot().CityConnections
+	assert.Equal(len(cityConnections), 2, "The city connections map should have 2 entries.")
+	assert.NotContains(cityConnections, "Foo", "Foo should not exist in the cityConnections map.")
 
-	assert.Contains(world.cityConnections, "Bee", "Bee should exist in the cityConnections map.")
-	beeConnections := world.cityConnections["Bee"]
+	assert.Contains(cityConnections, "Bee", "Bee should exist in the cityConnections map.")
+	beeConnections := cityConnections["Bee"]
 	assert.Equal(len(beeConnections), 1, "Bee shuold have 1 connections.")
 	assert.Contains(beeConnections, "Bar", "Bar should be a connection of Bee.")
 
-	assert.Contains(world.cityConnections, "Bar", "Bar should exist in the cityConnections map.")
-	barConnections := world.cityConnections["Bar"]
+	assert.Contains(cityConnections, "Bar", "Bar should exist in the cityConnections map.")
+	barConnections := cityConnections["Bar"]
 	assert.Equal(len(barConnections), 1, "Bar shuold have 1 connection.")
 	assert.Contains(barConnections, "Bee", "Bee should be a connection of Bar.")
 	assert.NotContains(barConnections, "Foo", "Foo should be a connection of Bar.")
@@ -184,10 +191,10 @@ func TestFreeAliens(t *testing.T) {
 	}
 
 	world.InitializeWorld(mapInfo)
-	alien := CreateAlien(0)
-	world.AddAlienToCity(alien, world.cities["Foo"], SpawningAliens)
-	trappedAlien := CreateAlien(1)
-	world.AddAlienToCity(trappedAlien, world.cities["Baz"], SpawningAliens)
+	alien := CreateAlien(0, newTestRand())
+	world.AddAlienToCity(alien, world.cities["Foo"])
+	trappedAlien := CreateAlien(1, newTestRand())
+	world.AddAlienToCity(trappedAlien, world.cities["Baz"])
 
 	freeAliens, err := world.GetFreeAliens()
 	assert.Nil(err, "Should be returning all free aliens without an error.")
@@ -226,10 +233,10 @@ func TestIsAlienAlive(t *testing.T) {
 	_, err := world.IsAlienAlive(nilAlien)
 	assert.NotNil(err, "Should not be able to handle nil alien")
 
-	alien := CreateAlien(0)
-	world.AddAlienToCity(alien, world.cities["Foo"], SpawningAliens)
+	alien := CreateAlien(0, newTestRand())
+	world.AddAlienToCity(alien, world.cities["Foo"])
 
-	deadAlien := CreateAlien(1)
+	deadAlien := CreateAlien(1, newTestRand())
 	alive, err := world.IsAlienAlive(deadAlien)
 	assert.Nil(err, "Should return false for a dead alien")
 	assert.False(alive, "Should return false for a dead alien")
@@ -251,8 +258,8 @@ func TestAllAliens(t *testing.T) {
 
 	world.InitializeWorld(mapInfo)
 	foo := world.cities["Foo"]
-	alien := CreateAlien(0)
-	world.AddAlienToCity(alien, foo, SpawningAliens)
+	alien := CreateAlien(0, newTestRand())
+	world.AddAlienToCity(alien, foo)
 
 	dead := world.AllAliensDead()
 	assert.False(dead, "There is one alien alive.")
@@ -281,37 +288,295 @@ func TestAddAlienToCity(t *testing.T) {
 	foo := world.cities["Foo"]
 	var nilAlien *Alien = nil
 
-	added, err := world.AddAlienToCity(nilAlien, foo, SpawningAliens)
+	added, err := world.AddAlienToCity(nilAlien, foo)
 	assert.False(added, "Alien should not have been added.")
 	assert.NotNil(err, "Nil alien should raise an error.")
 
-	alien := CreateAlien(0)
+	alien := CreateAlien(0, newTestRand())
 	var nilCity *City = nil
-	added, err = world.AddAlienToCity(alien, nilCity, SpawningAliens)
+	added, err = world.AddAlienToCity(alien, nilCity)
 	assert.False(added, "Alien should not have been added.")
 	assert.NotNil(err, "Nil city should raise an error.")
 
 	nonExistentCity := CreateCity("NonExistent")
-	added, err = world.AddAlienToCity(alien, nonExistentCity, SpawningAliens)
+	added, err = world.AddAlienToCity(alien, nonExistentCity)
 	assert.False(added, "Alien should not have been added.")
 	assert.NotNil(err, "Nonexistent city should raise an error.")
 
-	alienTwo := CreateAlien(1)
-	added, err = world.AddAlienToCity(alien, foo, SpawningAliens)
+	alienTwo := CreateAlien(1, newTestRand())
+	added, err = world.AddAlienToCity(alien, foo)
 	assert.True(added, "Alien should have been added successfully.")
 	assert.Nil(err, "Alien should have been added without an error.")
 
-	added, err = world.AddAlienToCity(alienTwo, foo, SpawningAliens)
+	added, err = world.AddAlienToCity(alienTwo, foo)
 	assert.False(added, "Alien should not have been but rather destroyed.")
 	assert.Nil(err, "No error should have been raised for destroying the alien.")
 
-	alienThree := CreateAlien(1)
+	alienThree := CreateAlien(1, newTestRand())
 	bar := world.cities["Bar"]
-	added, err = world.AddAlienToCity(alienThree, bar, SpawningAliens)
+	added, err = world.AddAlienToCity(alienThree, bar)
 	assert.True(added, "Alien should have been added successfully.")
 	assert.Nil(err, "Alien should have been added without an error.")
 	baz := world.cities["Baz"]
-	added, err = world.AddAlienToCity(alienThree, baz, MovingAliens)
+	added, err = world.AddAlienToCity(alienThree, baz)
 	assert.True(added, "Alien should have been added successfully.")
 	assert.Nil(err, "Alien should have been added without an error.")
 }
+
+func TestWriteMap(t *testing.T) {
+	assert := assert.New(t)
+
+	world := CreateWorld()
+	mapInfo := map[string][]string{
+		"Foo":   {"north=Bar", "west=Baz", "south=Qu-ux"},
+		"Bar":   {"south=Foo", "west=Bee"},
+		"Baz":   {"east=Foo"},
+		"Qu-ux": {"north=Foo"},
+		"Bee":   {"east=Bar"},
+	}
+	world.InitializeWorld(mapInfo)
+	world.RemoveCity(world.cities["Bee"])
+
+	var out strings.Builder
+	err := world.WriteMap(&out)
+	assert.Nil(err, "Writing the world should not fail.")
+	expected := "Bar south=Foo\n" +
+		"Baz east=Foo\n" +
+		"Foo north=Bar south=Qu-ux west=Baz\n" +
+		"Qu-ux north=Foo\n"
+	assert.Equal(expected, out.String(), "Remaining world should be written in the map file format.")
+
+	// the written map should load back into an identical world
+	fileName := filepath.Join(t.TempDir(), "map.txt")
+	err = os.WriteFile(fileName, []byte(out.String()), 0600)
+	if err != nil {
+		t.Fatalf("Writing map file failed: %v", err)
+	}
+	reloadedInfo, err := utils.ParseInputFile(fileName)
+	assert.Nil(err, "Written map should be parsable.")
+	reloaded := CreateWorld()
+	reloaded.InitializeWorld(reloadedInfo)
+
+	var reloadedOut strings.Builder
+	reloaded.WriteMap(&reloadedOut)
+	assert.Equal(out.String(), reloadedOut.String(), "The map should round-trip.")
+}
+
+func TestInitializeWorldSkipsMalformedRoads(t *testing.T) {
+	assert := assert.New(t)
+
+	world := CreateWorld()
+	mapInfo := map[string][]string{
+		"Foo": {"north=Bar", "east", ""},
+		"Bar": {"south=Foo"},
+	}
+
+	assert.NotPanics(func() { world.InitializeWorld(mapInfo) }, "Malformed roads should be skipped.")
+	assert.Equal(len(world.cities), 2, "The numbers of cities should be 2.")
+	assert.Equal(len(world.cities["Foo"].Neighbours), 1, "Foo should have 1 neighbour.")
+}
+
+func TestWorldEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	world := CreateWorld()
+	mapInfo := map[string][]string{
+		"Foo": {"north=Bar"},
+		"Bar": {"south=Foo", "west=Bee"},
+		"Bee": {"east=Bar"},
+	}
+	world.InitializeWorld(mapInfo)
+
+	events := []Event{}
+	world.SetEventRecorder(EventRecorderFunc(func(event Event) {
+		events = append(events, event)
+	}))
+
+	alien := CreateAlien(0, newTestRand())
+	world.AddAlienToCity(alien, world.cities["Foo"])
+	firstAlien := CreateAlien(1, newTestRand())
+	world.AddAlienToCity(firstAlien, world.cities["Bar"])
+	secondAlien := CreateAlien(2, newTestRand())
+	world.AddAlienToCity(secondAlien, world.cities["Bar"])
+
+	assert.Equal([]Event{
+		{Type: FightOccurred, AlienIDs: []int{1, 2}, AlienNames: []string{firstAlien.Name, secondAlien.Name}, City: "Bar", Resolver: "mutual-destruction"},
+		{Type: RoadRemoved, City: "Bar", From: "Bee", Direction: "east"},
+		{Type: RoadRemoved, City: "Bar", From: "Foo", Direction: "north"},
+		{Type: AlienTrapped, AlienIDs: []int{0}, AlienNames: []string{alien.Name}, City: "Foo"},
+		{Type: CityDestroyed, City: "Bar"},
+	}, events, "The fight and its consequences should be recorded in order.")
+}
+
+func TestAliensShareCity(t *testing.T) {
+	assert := assert.New(t)
+
+	mapInfo := map[string][]string{
+		"Foo": {"north=Bar"},
+		"Bar": {"south=Foo", "east=Bee"},
+		"Bee": {"west=Bar"},
+	}
+	world := CreateWorld()
+	world.InitializeWorld(mapInfo)
+	world.SetFightResolver(ThresholdResolver{Threshold: 3}, newTestRand())
+
+	events := []Event{}
+	world.SetEventRecorder(EventRecorderFunc(func(event Event) {
+		events = append(events, event)
+	}))
+
+	aliens := []*Alien{{ID: 0, Name: "a_0"}, {ID: 1, Name: "b_1"}, {ID: 2, Name: "c_2"}}
+	world.AddAlienToCity(aliens[0], world.cities["Foo"])
+	world.AddAlienToCity(aliens[1], world.cities["Foo"])
+	assert.Equal(aliens[:2], world.GetAliensInCity("Foo"), "Aliens below the threshold should share the city.")
+	assert.Equal(0, len(events), "Sharing a city should not be a fight.")
+
+	// both aliens get trapped once Foo loses its only road
+	world.AddAlienToCity(aliens[2], world.cities["Bar"])
+	world.RemoveCity(world.cities["Bee"])
+	world.RemoveCity(world.cities["Bar"])
+	assert.True(world.AllAliensTrapped(), "Every alien in an isolated city should be trapped.")
+	assert.Equal([]Event{
+		{Type: RoadRemoved, City: "Bee", From: "Bar", Direction: "east"},
+		{Type: CityDestroyed, City: "Bee"},
+		{Type: RoadRemoved, City: "Bar", From: "Foo", Direction: "north"},
+		{Type: AlienTrapped, AlienIDs: []int{0}, AlienNames: []string{"a_0"}, City: "Foo"},
+		{Type: AlienTrapped, AlienIDs: []int{1}, AlienNames: []string{"b_1"}, City: "Foo"},
+		{Type: CityDestroyed, City: "Bar"},
+	}, events, "Every alien in the isolated city should be reported as trapped.")
+	alive, _ := world.IsAlienAlive(aliens[2])
+	assert.False(alive, "Aliens in a destroyed city should die with it.")
+
+	// a third alien reaches the threshold and every alien in the city fights
+	events = events[:0]
+	third := &Alien{ID: 3, Name: "d_3"}
+	world.AddAlienToCity(third, world.cities["Foo"])
+	assert.Equal(Event{
+		Type:       FightOccurred,
+		AlienIDs:   []int{0, 1, 3},
+		AlienNames: []string{"a_0", "b_1", "d_3"},
+		City:       "Foo",
+		Resolver:   "threshold:3",
+	}, events[0], "The fight should report every participant.")
+	assert.True(world.AllAliensDead(), "Every participant should die.")
+	assert.Equal(0, len(world.GetAliensInCity("Foo")), "No alien should be left in the city.")
+}
+
+func TestMoveAliensSimultaneously(t *testing.T) {
+	assert := assert.New(t)
+
+	// aliens swapping cities meet on the road, which is destroyed along with them
+	world := createMovementTestWorld()
+	events := []Event{}
+	world.SetEventRecorder(EventRecorderFunc(func(event Event) {
+		events = append(events, event)
+	}))
+	hubAlien, northAlien := spawnTestAlien(world, 0, "Hub"), spawnTestAlien(world, 1, "North")
+	err := world.MoveAliensSimultaneously([]AlienMove{{Alien: hubAlien, To: world.cities["North"]}, {Alien: northAlien, To: world.cities["Hub"]}})
+	assert.Nil(err)
+	assert.Equal([]Event{
+		{Type: FightOccurred, AlienIDs: []int{0, 1}, AlienNames: []string{"alien", "alien"}, City: "North", From: "Hub", Direction: "north", Resolver: "mutual-destruction"},
+		{Type: RoadRemoved, City: "North", From: "Hub", Direction: "north"},
+		{Type: RoadRemoved, City: "Hub", From: "North", Direction: "south"},
+	}, events, "The fight on the road should destroy the road.")
+	assert.True(world.AllAliensDead(), "Both aliens should die on the road.")
+	_, hubStands := world.GetCity("Hub")
+	_, northStands := world.GetCity("North")
+	assert.True(hubStands && northStands, "Both ends of the road should stand.")
+	assert.False(world.cities["North"].HasNeighbours(), "North should be cut off.")
+
+	// aliens below the threshold pass each other
+	world = createMovementTestWorld()
+	world.SetFightResolver(ThresholdResolver{Threshold: 3}, newTestRand())
+	hubAlien, northAlien = spawnTestAlien(world, 0, "Hub"), spawnTestAlien(world, 1, "North")
+	world.MoveAliensSimultaneously([]AlienMove{{Alien: hubAlien, To: world.cities["North"]}, {Alien: northAlien, To: world.cities["Hub"]}})
+	assert.Equal("North", hubAlien.Location.Name, "The aliens should swap cities.")
+	assert.Equal("Hub", northAlien.Location.Name, "The aliens should swap cities.")
+
+	// aliens arriving together fight with each other and with the alien staying in the city
+	world = createMovementTestWorld()
+	world.SetFightResolver(fixedWinnerResolver{winnerID: 2}, newTestRand())
+	spawnTestAlien(world, 0, "Hub")
+	northAlien, westAlien, eastAlien := spawnTestAlien(world, 1, "North"), spawnTestAlien(world, 2, "West"), spawnTestAlien(world, 3, "East")
+	world.MoveAliensSimultaneously([]AlienMove{
+		{Alien: eastAlien, To: world.cities["Far"]},
+		{Alien: northAlien, To: world.cities["Hub"]},
+		{Alien: westAlien, To: world.cities["Hub"]},
+	})
+	assert.Equal([]*Alien{westAlien}, world.GetAliensInCity("Hub"), "The winner should hold the city.")
+	assert.Equal([]*Alien{eastAlien}, world.GetAliensInCity("Far"), "An alien arriving alone should not fight.")
+	aliens, _ := world.GetAllAliens()
+	assert.Equal(2, len(aliens), "The losers should die.")
+
+	// an alien following another one into the city it leaves does not meet it
+	world = createMovementTestWorld()
+	hubAlien, northAlien = spawnTestAlien(world, 0, "Hub"), spawnTestAlien(world, 1, "North")
+	world.MoveAliensSimultaneously([]AlienMove{{Alien: northAlien, To: world.cities["Hub"]}, {Alien: hubAlien, To: world.cities["East"]}})
+	assert.Equal([]*Alien{northAlien}, world.GetAliensInCity("Hub"), "The follower should arrive in the vacated city.")
+	assert.Equal([]*Alien{hubAlien}, world.GetAliensInCity("East"), "The leader should arrive in its destination.")
+}
+
+// gridMapInfo returns the map of a width x height grid of cities named after their coordinates,
+// every city linked to the cities next to it
+func gridMapInfo(width, height int) map[string][]string {
+	name := func(x, y int) string { return strconv.Itoa(x) + "-" + strconv.Itoa(y) }
+	mapInfo := make(map[string][]string, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			roads := []string{}
+			if y > 0 {
+				roads = append(roads, "north="+name(x, y-1))
+			}
+			if x < width-1 {
+				roads = append(roads, "east="+name(x+1, y))
+			}
+			if y < height-1 {
+				roads = append(roads, "south="+name(x, y+1))
+			}
+			if x > 0 {
+				roads = append(roads, "west="+name(x-1, y))
+			}
+			mapInfo[name(x, y)] = roads
+		}
+	}
+	return mapInfo
+}
+
+// BenchmarkSpawnAlien spawns aliens with every spawn strategy, destroying the cities they collide in.
+// The world is rebuilt once no city is left to spawn in. The time per alien should not grow with the
+// size of the world
+func BenchmarkSpawnAlien(b *testing.B) {
+	for _, width := range []int{100, 300, 1000} {
+		mapInfo := gridMapInfo(width, width)
+		strategies := []SpawnStrategy{
+			UniformSpawnStrategy{},
+			NoCollisionSpawnStrategy{},
+			DegreeWeightedSpawnStrategy{},
+			ClusteredSpawnStrategy{Center: strconv.Itoa(width/2) + "-" + strconv.Itoa(width/2), Radius: 2},
+		}
+		for _, strategy := range strategies {
+			strategy := strategy
+			name := strings.SplitN(strategy.String(), ":", 2)[0]
+			b.Run(name+"/"+strconv.Itoa(width*width)+"-cities", func(b *testing.B) {
+				b.ReportAllocs()
+				rng := newTestRand()
+				world := CreateWorld()
+				world.InitializeWorld(mapInfo)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if len(world.unoccupiedCities) == 0 {
+						b.StopTimer()
+						world = CreateWorld()
+						world.InitializeWorld(mapInfo)
+						b.StartTimer()
+					}
+					city, err := strategy.SpawnCity(world, &Alien{ID: i}, rng)
+					if err != nil {
+						b.Fatal(err)
+					}
+					world.AddAlienToCity(&Alien{ID: i, Name: "alien"}, city)
+				}
+			})
+		}
+	}
+}
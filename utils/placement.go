@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParsePlacementFile reads the placement file with the given name, see ParsePlacements
+func ParsePlacementFile(fname string) (map[int]string, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, fmt.Errorf("%v\n", err)
+	}
+	defer file.Close()
+	return ParsePlacements(file)
+}
+
+// ParsePlacements reads the cities the aliens spawn in, mapped by alien ID. Every line holds
+// an alien ID followed by the name of a city (e.g. "0 Foo"), and empty lines are skipped
+func ParsePlacements(in io.Reader) (map[int]string, error) {
+	placements := make(map[int]string)
+
+	lineScanner := bufio.NewScanner(in)
+	for lineNumber := 1; lineScanner.Scan(); lineNumber++ {
+		fields := strings.Fields(lineScanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, &InvalidPlacementLineError{line: lineNumber, reason: "expected an alien ID and a city"}
+		}
+		alienID, err := strconv.Atoi(fields[0])
+		if err != nil || alienID < 0 {
+			return nil, &InvalidPlacementLineError{line: lineNumber, reason: "invalid alien ID " + fields[0]}
+		}
+		if _, exists := placements[alienID]; exists {
+			return nil, &InvalidPlacementLineError{line: lineNumber, reason: "alien " + fields[0] + " is already placed"}
+		}
+		placements[alienID] = fields[1]
+	}
+	if err := lineScanner.Err(); err != nil {
+		return nil, err
+	}
+	return placements, nil
+}
+
+// error triggered when parsing a malformed line of a placement file
+type InvalidPlacementLineError struct {
+	line   int
+	reason string
+}
+
+func (err *InvalidPlacementLineError) Error() string {
+	return fmt.Sprintf("Invalid placement on line %d: %s.\n", err.line, err.reason)
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePlacements(t *testing.T) {
+	assert := assert.New(t)
+
+	placements, err := ParsePlacements(strings.NewReader("0 Foo\n\n2  Bar\n1 Foo\n"))
+	assert.Nil(err, "The placements should be valid.")
+	assert.Equal(map[int]string{0: "Foo", 1: "Foo", 2: "Bar"}, placements, "Every alien should be placed in its city.")
+
+	for _, contents := range []string{"0\n", "0 Foo Bar\n", "x Foo\n", "-1 Foo\n", "0 Foo\n0 Bar\n"} {
+		_, err := ParsePlacements(strings.NewReader(contents))
+		assert.IsType(&InvalidPlacementLineError{}, err, "Placements %q should be invalid.", contents)
+	}
+}
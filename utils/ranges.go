@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseIntRange parses a list of integers given either as a comma-separated list ("5,10,20"),
+// as an inclusive range ("5:50") or as an inclusive range with a step ("5:50:5")
+func ParseIntRange(spec string) ([]int, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, &InvalidRangeError{spec: spec, reason: "empty range"}
+	}
+
+	if !strings.Contains(spec, ":") {
+		values := []int{}
+		for _, part := range strings.Split(spec, ",") {
+			value, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return nil, &InvalidRangeError{spec: spec, reason: err.Error()}
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+
+	parts := strings.Split(spec, ":")
+	if len(parts) > 3 {
+		return nil, &InvalidRangeError{spec: spec, reason: "expected start:end or start:end:step"}
+	}
+	bounds := []int{}
+	for _, part := range parts {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, &InvalidRangeError{spec: spec, reason: err.Error()}
+		}
+		bounds = append(bounds, value)
+	}
+	start, end, step := bounds[0], bounds[1], 1
+	if len(bounds) == 3 {
+		step = bounds[2]
+	}
+	if step <= 0 {
+		return nil, &InvalidRangeError{spec: spec, reason: "step must be positive"}
+	}
+	if end < start {
+		return nil, &InvalidRangeError{spec: spec, reason: "end must not be smaller than start"}
+	}
+
+	values := []int{}
+	for value := start; value <= end; value += step {
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// ParseNonNegativeIntRange parses a list of integers like ParseIntRange, rejecting negative integers
+func ParseNonNegativeIntRange(spec string) ([]int, error) {
+	values, err := ParseIntRange(spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		if value < 0 {
+			return nil, &InvalidRangeError{spec: strings.TrimSpace(spec), reason: "values must not be negative"}
+		}
+	}
+	return values, nil
+}
+
+// error triggered when parsing an invalid range of integers
+type InvalidRangeError struct {
+	spec   string
+	reason string
+}
+
+func (err *InvalidRangeError) Error() string {
+	return fmt.Sprintf("Invalid range %q: %s.\n", err.spec, err.reason)
+}
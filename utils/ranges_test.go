@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIntRange(t *testing.T) {
+	assert := assert.New(t)
+
+	for spec, expected := range map[string][]int{
+		"7":        {7},
+		"5,10, 20": {5, 10, 20},
+		"1:4":      {1, 2, 3, 4},
+		"5:50:15":  {5, 20, 35, 50},
+		"5:49:15":  {5, 20, 35},
+	} {
+		values, err := ParseIntRange(spec)
+		assert.Nil(err, "Range %q should be valid.", spec)
+		assert.Equal(expected, values, "Range %q should be parsed.", spec)
+	}
+
+	for _, spec := range []string{"", "a", "1:2:3:4", "5:1", "1:5:0", "1,b"} {
+		_, err := ParseIntRange(spec)
+		assert.IsType(&InvalidRangeError{}, err, "Range %q should be invalid.", spec)
+	}
+}
+
+func TestParseNonNegativeIntRange(t *testing.T) {
+	assert := assert.New(t)
+
+	values, err := ParseNonNegativeIntRange("0:2")
+	assert.Nil(err, "Ranges from 0 should be valid.")
+	assert.Equal([]int{0, 1, 2}, values)
+
+	for _, spec := range []string{"-2:2", "5,-1", "-3", "a"} {
+		_, err := ParseNonNegativeIntRange(spec)
+		assert.IsType(&InvalidRangeError{}, err, "Range %q should be invalid.", spec)
+	}
+}
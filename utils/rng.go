@@ -0,0 +1,46 @@
+package utils
+
+import "math/rand"
+
+// RandomSource is a splitmix64 random source whose whole state is a single number,
+// so that it can be saved and restored to continue the exact same sequence
+type RandomSource struct {
+	state uint64
+}
+
+// check that RandomSource can be used as the source of a rand.Rand
+var _ rand.Source64 = (*RandomSource)(nil)
+
+// CreateRandomSource constructs a random source with the provided seed
+func CreateRandomSource(seed int64) *RandomSource {
+	return &RandomSource{state: uint64(seed)}
+}
+
+// Uint64 returns the next pseudo-random 64-bit value
+func (s *RandomSource) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int63 returns the next pseudo-random non-negative 63-bit value
+func (s *RandomSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Seed resets the source to the sequence of the provided seed
+func (s *RandomSource) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// State returns the current state of the source
+func (s *RandomSource) State() uint64 {
+	return s.state
+}
+
+// SetState restores a state previously returned by State
+func (s *RandomSource) SetState(state uint64) {
+	s.state = state
+}
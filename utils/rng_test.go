@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomSourceIsSeeded(t *testing.T) {
+	assert := assert.New(t)
+
+	first := rand.New(CreateRandomSource(42))
+	second := rand.New(CreateRandomSource(42))
+	for i := 0; i < 100; i++ {
+		assert.Equal(first.Intn(1000), second.Intn(1000), "The same seed should produce the same sequence.")
+	}
+}
+
+func TestRandomSourceRestoresState(t *testing.T) {
+	assert := assert.New(t)
+
+	source := CreateRandomSource(7)
+	rng := rand.New(source)
+	for i := 0; i < 10; i++ {
+		rng.Intn(100)
+	}
+
+	state := source.State()
+	expected := []int{}
+	for i := 0; i < 10; i++ {
+		expected = append(expected, rng.Intn(100))
+	}
+
+	restored := CreateRandomSource(0)
+	restored.SetState(state)
+	restoredRng := rand.New(restored)
+	for i := 0; i < 10; i++ {
+		assert.Equal(expected[i], restoredRng.Intn(100), "A restored source should continue the same sequence.")
+	}
+}
@@ -3,10 +3,10 @@ package utils
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/phuslu/log"
 )
@@ -32,16 +32,31 @@ func ParseInputFile(fname string) (map[string][]string, error) {
 	return mapInfo, nil
 }
 
-func GenerateRandomNumber(n int) (int, error) {
+// GenerateRandomNumber returns a random number in [0, n) drawn from the given source
+func GenerateRandomNumber(rng *rand.Rand, n int) (int, error) {
 	r := 0
 	if n <= 0 {
 		return r, &GenerateRandomNumberError{bound: n}
 	}
-	rand.Seed(time.Now().UnixNano())
-	r = rand.Intn(n)
+	r = rng.Intn(n)
 	return r, nil
 }
 
+// GeneratePetName returns a pet name of the given number of words drawn from the given source, like
+// the petname package does: a name, after an adjective unless a single word is asked for, after adverbs
+// for any words beyond two
+func GeneratePetName(rng *rand.Rand, wordCount int, separator string) string {
+	if wordCount == 1 {
+		return petNames[rng.Intn(len(petNames))]
+	}
+	words := []string{}
+	for i := 0; i < wordCount-2; i++ {
+		words = append(words, petAdverbs[rng.Intn(len(petAdverbs))])
+	}
+	words = append(words, petAdjectives[rng.Intn(len(petAdjectives))], petNames[rng.Intn(len(petNames))])
+	return strings.Join(words, separator)
+}
+
 func InitializeLogger() (log.Logger, log.Logger) {
 	defaultLogger := log.Logger{
 		TimeFormat: "15:04:05",
@@ -66,6 +81,15 @@ func InitializeLogger() (log.Logger, log.Logger) {
 	return defaultLogger, debugLogger
 }
 
+// InitializeSilentLogger returns loggers which discard everything, for simulations run in bulk
+func InitializeSilentLogger() (log.Logger, log.Logger) {
+	silentLogger := log.Logger{
+		Level:  log.PanicLevel + 1,
+		Writer: log.IOWriter{Writer: io.Discard},
+	}
+	return silentLogger, silentLogger
+}
+
 // error triggered when trying to generate a random number with an invalid upper bound
 type GenerateRandomNumberError struct {
 	bound int
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePetName(t *testing.T) {
+	assert := assert.New(t)
+
+	for wordCount, expectedWords := range map[int]int{1: 1, 2: 2, 3: 3, 5: 5} {
+		name := GeneratePetName(rand.New(rand.NewSource(1)), wordCount, "-")
+		assert.Equal(expectedWords, len(strings.Split(name, "-")), "Name %q should have %d words.", name, expectedWords)
+	}
+
+	rng := rand.New(rand.NewSource(7))
+	names := []string{}
+	for i := 0; i < 10; i++ {
+		names = append(names, GeneratePetName(rng, 2, "-"))
+	}
+	rand.Seed(42)
+	rng = rand.New(rand.NewSource(7))
+	for i := 0; i < 10; i++ {
+		rand.Int63()
+		assert.Equal(names[i], GeneratePetName(rng, 2, "-"), "Names should only depend on the given source.")
+	}
+}